@@ -4,15 +4,17 @@
 // Source code and contact info at http://github.com/streadway/handy
 
 /*
-Package accept contains filters to reject requests without a specified Accept
-header with "406 Not Acceptable".
+Package accept contains filters to negotiate the media type and language of
+the response from the Accept and Accept-Language headers and reject requests
+without an acceptable representation with "406 Not Acceptable".  It also
+contains filters to reject request bodies of unsupported media types with
+"415 Unsupported Media Type".
 */
 package accept
 
 import (
-	"mime"
+	"context"
 	"net/http"
-	"strings"
 )
 
 const (
@@ -37,43 +39,45 @@ var (
 	XML = Middleware("application/xhtml+xml", "application/xml")
 )
 
+type contextKey int
+
+const (
+	mediaTypeKey contextKey = iota
+	languageKey
+	versionKey
+)
+
+// MediaType returns the media type negotiated by Middleware for the request
+// context.  The second result is false when no media type was negotiated.
+func MediaType(ctx context.Context) (string, bool) {
+	mediaType, ok := ctx.Value(mediaTypeKey).(string)
+	return mediaType, ok
+}
+
 // Middleware returns a composable handler factory to restrict accepted
-// media types and respond with "406 Not Acceptable" otherwise.
+// media types and respond with "406 Not Acceptable" otherwise.  The media
+// types are offered in order of the server's preference, the one preferred
+// by the client is available to the next handler through MediaType.
 func Middleware(mediaTypes ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if !acceptable(r.Header.Get("Accept"), mediaTypes) {
+			w.Header().Add("Vary", "Accept")
+
+			mediaType, ok := Negotiate(r.Header.Get("Accept"), mediaTypes...)
+			if !ok {
 				w.WriteHeader(http.StatusNotAcceptable)
 				return
 			}
+
+			if mediaType != "" {
+				r = r.WithContext(context.WithValue(r.Context(), mediaTypeKey, mediaType))
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
 func acceptable(accept string, mediaTypes []string) bool {
-	if accept == "" || accept == ALL {
-		// The absense of an Accept header is equivalent to "*/*".
-		// https://tools.ietf.org/html/rfc2296#section-4.2.2
-		return true
-	}
-
-	for _, a := range strings.Split(accept, ",") {
-		mediaType, _, err := mime.ParseMediaType(a)
-		if err != nil {
-			continue
-		}
-
-		if mediaType == ALL {
-			return true
-		}
-
-		for _, t := range mediaTypes {
-			if mediaType == t {
-				return true
-			}
-		}
-	}
-
-	return false
+	_, ok := Negotiate(accept, mediaTypes...)
+	return ok
 }
@@ -47,6 +47,11 @@ func TestMiddleware(t *testing.T) {
 			middleware: JSON,
 			code:       http.StatusOK,
 		},
+		{
+			accept:     "application/json; charset=utf-8",
+			middleware: JSON,
+			code:       http.StatusOK,
+		},
 		{
 			accept:     "text/plain",
 			middleware: Plain,
@@ -57,6 +62,26 @@ func TestMiddleware(t *testing.T) {
 			middleware: XML,
 			code:       http.StatusOK,
 		},
+		{
+			accept:     "application/*",
+			middleware: JSON,
+			code:       http.StatusOK,
+		},
+		{
+			accept:     "text/*",
+			middleware: JSON,
+			code:       http.StatusNotAcceptable,
+		},
+		{
+			accept:     "application/problem+json",
+			middleware: JSON,
+			code:       http.StatusOK,
+		},
+		{
+			accept:     "text/plain",
+			middleware: EventStream,
+			code:       http.StatusOK,
+		},
 	}
 
 	for _, tt := range tests {
@@ -107,6 +132,11 @@ func TestAcceptable(t *testing.T) {
 			types:  []string{"application/json"},
 			want:   true,
 		},
+		{
+			accept: "application/json;q=0",
+			types:  []string{"application/json"},
+			want:   false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -120,6 +150,24 @@ func TestAcceptable(t *testing.T) {
 	}
 }
 
+func TestMiddlewareMediaType(t *testing.T) {
+	var got string
+	h := Middleware("application/json", "text/html")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = MediaType(r.Context())
+	}))
+
+	w, r := httptest.NewRecorder(), newRequest("application/json;q=0.5, text/html")
+	h.ServeHTTP(w, r)
+
+	if want := "text/html"; want != got {
+		t.Fatalf("want negotiated media type %q, got %q", want, got)
+	}
+
+	if want, got := "Accept", w.Header().Get("Vary"); want != got {
+		t.Fatalf("want Vary %q, got %q", want, got)
+	}
+}
+
 var okHandler http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {}
 
 func newRequest(accept string) *http.Request {
@@ -0,0 +1,96 @@
+// Copyright (c) 2015, SoundCloud Ltd.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the README file.
+// Source code and contact info at http://github.com/streadway/handy
+
+package accept
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ContentType returns a composable handler factory to restrict the media
+// types of request bodies and respond with "415 Unsupported Media Type"
+// otherwise.  Media types may be ranges like "text/*" or "application/*+json"
+// and may carry parameters, like a charset, which must match when the request
+// sets them too.
+//
+// Requests are checked when they carry a body, or when their method is POST,
+// PUT or PATCH unless they are empty without a Content-Type.  Rejected POST
+// and PATCH requests are answered with the Accept-Post or Accept-Patch header
+// listing the supported media types.
+func ContentType(mediaTypes ...string) func(http.Handler) http.Handler {
+	var allowed []mediaRange
+	for _, t := range mediaTypes {
+		if r, ok := parseMediaRange(t); ok {
+			allowed = append(allowed, r)
+		}
+	}
+
+	accepted := strings.Join(mediaTypes, ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if hasBody(r) && !supported(r.Header.Get("Content-Type"), allowed) {
+				switch r.Method {
+				case "POST":
+					w.Header().Set("Accept-Post", accepted)
+				case "PATCH":
+					w.Header().Set("Accept-Patch", accepted)
+				}
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// hasBody reports whether the request is expected to carry a body.  Requests
+// known to be empty without a Content-Type, like actions posted without a
+// body, carry none whatever their method.
+func hasBody(r *http.Request) bool {
+	if r.ContentLength == 0 && r.Header.Get("Content-Type") == "" {
+		return false
+	}
+
+	switch r.Method {
+	case "POST", "PUT", "PATCH":
+		return true
+	}
+	return r.ContentLength != 0
+}
+
+// supported reports whether the Content-Type falls within one of the allowed
+// ranges.
+func supported(contentType string, allowed []mediaRange) bool {
+	c, ok := parseMediaRange(contentType)
+	if !ok || c.wildcard() {
+		return false
+	}
+
+	for _, a := range allowed {
+		if a.includes(c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// includes reports whether the concrete media type is within the range and
+// agrees with every parameter of the range it sets.
+func (r mediaRange) includes(other mediaRange) bool {
+	if !r.contains(other) {
+		return false
+	}
+
+	for k, v := range r.params {
+		if ov, ok := other.params[k]; ok && !strings.EqualFold(ov, v) {
+			return false
+		}
+	}
+
+	return true
+}
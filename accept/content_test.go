@@ -0,0 +1,141 @@
+// Copyright (c) 2015, SoundCloud Ltd.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the README file.
+// Source code and contact info at http://github.com/streadway/handy
+
+package accept
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContentType(t *testing.T) {
+	tests := []struct {
+		method        string
+		contentType   string
+		contentLength int64
+		types         []string
+		code          int
+	}{
+		{
+			method: "GET",
+			types:  []string{"application/json"},
+			code:   http.StatusOK,
+		},
+		{
+			method:      "POST",
+			contentType: "application/json",
+			types:       []string{"application/json"},
+			code:        http.StatusOK,
+		},
+		{
+			method:        "POST",
+			contentLength: -1,
+			types:         []string{"application/json"},
+			code:          http.StatusUnsupportedMediaType,
+		},
+		{
+			method: "POST",
+			types:  []string{"application/json"},
+			code:   http.StatusOK,
+		},
+		{
+			method:        "DELETE",
+			contentLength: 2,
+			types:         []string{"application/json"},
+			code:          http.StatusUnsupportedMediaType,
+		},
+		{
+			method:      "PUT",
+			contentType: "text/plain",
+			types:       []string{"application/json"},
+			code:        http.StatusUnsupportedMediaType,
+		},
+		{
+			method:      "PATCH",
+			contentType: "application/merge-patch+json",
+			types:       []string{"application/*+json"},
+			code:        http.StatusOK,
+		},
+		{
+			method:      "POST",
+			contentType: "text/csv; charset=utf-8",
+			types:       []string{"text/*"},
+			code:        http.StatusOK,
+		},
+		{
+			method:      "POST",
+			contentType: "application/json; charset=UTF-8",
+			types:       []string{"application/json; charset=utf-8"},
+			code:        http.StatusOK,
+		},
+		{
+			method:      "POST",
+			contentType: "application/json",
+			types:       []string{"application/json; charset=utf-8"},
+			code:        http.StatusOK,
+		},
+		{
+			method:      "POST",
+			contentType: "application/json; charset=latin1",
+			types:       []string{"application/json; charset=utf-8"},
+			code:        http.StatusUnsupportedMediaType,
+		},
+		{
+			method:      "POST",
+			contentType: "application/*",
+			types:       []string{"application/json"},
+			code:        http.StatusUnsupportedMediaType,
+		},
+		{
+			method:      "POST",
+			contentType: "invalid",
+			types:       []string{"application/json"},
+			code:        http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		w, r := httptest.NewRecorder(), &http.Request{
+			Method:        tt.method,
+			Header:        map[string][]string{},
+			ContentLength: tt.contentLength,
+		}
+		if tt.contentType != "" {
+			r.Header.Set("Content-Type", tt.contentType)
+		}
+
+		ContentType(tt.types...)(okHandler).ServeHTTP(w, r)
+
+		if want, got := tt.code, w.Code; want != got {
+			t.Errorf("%s %q with %v want status %d, got %d", tt.method, tt.contentType, tt.types, want, got)
+		}
+	}
+}
+
+func TestContentTypeAcceptHeaders(t *testing.T) {
+	h := ContentType("application/json", "application/xml")(okHandler)
+
+	tests := []struct {
+		method string
+		header string
+	}{
+		{"POST", "Accept-Post"},
+		{"PATCH", "Accept-Patch"},
+	}
+
+	for _, tt := range tests {
+		w, r := httptest.NewRecorder(), &http.Request{
+			Method: tt.method,
+			Header: map[string][]string{"Content-Type": {"text/plain"}},
+		}
+
+		h.ServeHTTP(w, r)
+
+		if want, got := "application/json, application/xml", w.Header().Get(tt.header); want != got {
+			t.Errorf("%s want %s %q, got %q", tt.method, tt.header, want, got)
+		}
+	}
+}
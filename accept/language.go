@@ -0,0 +1,191 @@
+// Copyright (c) 2015, SoundCloud Ltd.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the README file.
+// Source code and contact info at http://github.com/streadway/handy
+
+package accept
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Language returns the language tag negotiated by Languages for the request
+// context.  The second result is false when no language was negotiated.
+func Language(ctx context.Context) (string, bool) {
+	tag, ok := ctx.Value(languageKey).(string)
+	return tag, ok
+}
+
+// Languages returns a composable handler factory that negotiates the
+// language of the response from the Accept-Language header among the
+// supported tags, set in order of the server's preference.  The chosen tag is
+// sent as Content-Language and is available to the next handler through
+// Language.
+//
+// When none of the tags is acceptable the fallback tag is chosen, an empty
+// fallback responds with "406 Not Acceptable" instead.
+func Languages(fallback string, tags ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Language")
+
+			tag, ok := NegotiateLanguage(r.Header.Get("Accept-Language"), tags...)
+			if !ok {
+				tag = fallback
+			}
+
+			if tag == "" {
+				w.WriteHeader(http.StatusNotAcceptable)
+				return
+			}
+
+			w.Header().Set("Content-Language", tag)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), languageKey, tag)))
+		})
+	}
+}
+
+// languageRange is a single element of an Accept-Language header.
+type languageRange struct {
+	tag string
+	q   float64
+}
+
+// parseAcceptLanguage splits an Accept-Language header into its language
+// ranges ordered by descending quality, skipping any elements that cannot be
+// parsed.
+func parseAcceptLanguage(header string) []languageRange {
+	var ranges []languageRange
+	for _, l := range strings.Split(header, ",") {
+		tag, q := strings.TrimSpace(l), ""
+		if i := strings.IndexByte(tag, ';'); i >= 0 {
+			tag, q = strings.TrimSpace(tag[:i]), tag[i+1:]
+		}
+
+		if !validLanguageRange(tag) {
+			continue
+		}
+
+		r := languageRange{tag: strings.ToLower(tag), q: 1}
+		if q != "" {
+			quality, ok := parseQuality(q)
+			if !ok {
+				continue
+			}
+			r.q = quality
+		}
+
+		ranges = append(ranges, r)
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	return ranges
+}
+
+// validLanguageRange reports whether s is "*" or a sequence of alphanumeric
+// subtags of 1 to 8 characters separated by "-".
+// https://tools.ietf.org/html/rfc4647#section-2.1
+func validLanguageRange(s string) bool {
+	if s == "*" {
+		return true
+	}
+
+	for _, subtag := range strings.Split(s, "-") {
+		if len(subtag) < 1 || len(subtag) > 8 {
+			return false
+		}
+		for _, c := range subtag {
+			if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// filters reports whether the language range matches the tag by basic
+// filtering, that is the range equals the tag or is a prefix of it followed
+// by "-".
+// https://tools.ietf.org/html/rfc4647#section-3.3.1
+func (r languageRange) filters(tag string) bool {
+	tag = strings.ToLower(tag)
+	return r.tag == "*" || r.tag == tag || strings.HasPrefix(tag, r.tag+"-")
+}
+
+// truncate removes the last subtag of a language range, as well as a
+// preceding single character subtag.  It returns "" when nothing remains.
+// https://tools.ietf.org/html/rfc4647#section-3.4
+func truncate(tag string) string {
+	i := strings.LastIndexByte(tag, '-')
+	if i < 0 {
+		return ""
+	}
+
+	tag = tag[:i]
+	if i = strings.LastIndexByte(tag, '-'); i >= 0 && i == len(tag)-2 {
+		tag = tag[:i]
+	}
+
+	return tag
+}
+
+// NegotiateLanguage selects the tag that best satisfies the Accept-Language
+// header.  Language ranges are tried in order of their quality, each first by
+// lookup, progressively removing subtags so "de-AT" falls back to "de", then
+// by basic filtering so "de" selects "de-AT".  Tags excluded with "q=0" by
+// their most specific matching range are never selected.
+//
+// An empty Accept-Language header accepts any language, so the first tag is
+// returned.  The second result is false when no tag is acceptable.
+func NegotiateLanguage(acceptLanguage string, tags ...string) (string, bool) {
+	if len(tags) == 0 {
+		return "", false
+	}
+
+	if strings.TrimSpace(acceptLanguage) == "" {
+		return tags[0], true
+	}
+
+	ranges := parseAcceptLanguage(acceptLanguage)
+
+	// A tag is excluded when the most specific range matching it has q=0,
+	// so "de-AT, de;q=0" still accepts "de-AT".
+	excluded := func(tag string) bool {
+		longest, q := "", 1.0
+		for _, r := range ranges {
+			if r.tag != "*" && len(r.tag) > len(longest) && r.filters(tag) {
+				longest, q = r.tag, r.q
+			}
+		}
+		return q == 0
+	}
+
+	for _, r := range ranges {
+		if r.q == 0 {
+			break
+		}
+
+		// Lookup
+		for prefix := r.tag; prefix != "" && prefix != "*"; prefix = truncate(prefix) {
+			for _, tag := range tags {
+				if strings.EqualFold(tag, prefix) && !excluded(tag) {
+					return tag, true
+				}
+			}
+		}
+
+		// Basic filtering
+		for _, tag := range tags {
+			if r.filters(tag) && !excluded(tag) {
+				return tag, true
+			}
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2015, SoundCloud Ltd.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the README file.
+// Source code and contact info at http://github.com/streadway/handy
+
+package accept
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiateLanguage(t *testing.T) {
+	tests := []struct {
+		accept string
+		tags   []string
+		want   string
+		ok     bool
+	}{
+		{
+			accept: "",
+			tags:   []string{"en", "de"},
+			want:   "en",
+			ok:     true,
+		},
+		{
+			accept: "de",
+			tags:   []string{"en", "de"},
+			want:   "de",
+			ok:     true,
+		},
+		{
+			accept: "de-AT",
+			tags:   []string{"en", "de"},
+			want:   "de",
+			ok:     true,
+		},
+		{
+			accept: "zh-Hant-CN-x-private1",
+			tags:   []string{"zh", "zh-Hant"},
+			want:   "zh-Hant",
+			ok:     true,
+		},
+		{
+			accept: "de",
+			tags:   []string{"en", "de-AT"},
+			want:   "de-AT",
+			ok:     true,
+		},
+		{
+			accept: "DE-at",
+			tags:   []string{"en", "de-AT"},
+			want:   "de-AT",
+			ok:     true,
+		},
+		{
+			accept: "fr;q=0.5, de;q=0.8, en;q=0.1",
+			tags:   []string{"en", "fr", "de"},
+			want:   "de",
+			ok:     true,
+		},
+		{
+			accept: "*, en;q=0",
+			tags:   []string{"en", "fr"},
+			want:   "fr",
+			ok:     true,
+		},
+		{
+			accept: "de-AT, de;q=0",
+			tags:   []string{"de", "en"},
+			ok:     false,
+		},
+		{
+			accept: "de-AT, de;q=0",
+			tags:   []string{"de-AT", "en"},
+			want:   "de-AT",
+			ok:     true,
+		},
+		{
+			accept: "de, de-CH;q=0",
+			tags:   []string{"de-CH", "de-DE"},
+			want:   "de-DE",
+			ok:     true,
+		},
+		{
+			accept: "fr",
+			tags:   []string{"en", "de"},
+			ok:     false,
+		},
+		{
+			accept: "en-us;q=nope, not_a_tag, fr",
+			tags:   []string{"en", "fr"},
+			want:   "fr",
+			ok:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		got, ok := NegotiateLanguage(tt.accept, tt.tags...)
+		if ok != tt.ok {
+			t.Errorf("%q with %v want ok %t, got %t", tt.accept, tt.tags, tt.ok, ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q with %v want %q, got %q", tt.accept, tt.tags, tt.want, got)
+		}
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	var got string
+	h := Languages("en", "en", "de")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = Language(r.Context())
+	}))
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"de-AT", "de"},
+		{"fr", "en"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := &http.Request{Header: map[string][]string{
+			"Accept-Language": {tt.accept},
+		}}
+
+		h.ServeHTTP(w, r)
+
+		if tt.want != got {
+			t.Errorf("%q want language %q, got %q", tt.accept, tt.want, got)
+		}
+		if want, got := tt.want, w.Header().Get("Content-Language"); want != got {
+			t.Errorf("%q want Content-Language %q, got %q", tt.accept, want, got)
+		}
+		if want, got := "Accept-Language", w.Header().Get("Vary"); want != got {
+			t.Errorf("%q want Vary %q, got %q", tt.accept, want, got)
+		}
+	}
+}
+
+func TestLanguagesWithoutFallback(t *testing.T) {
+	w, r := httptest.NewRecorder(), &http.Request{Header: map[string][]string{
+		"Accept-Language": {"fr"},
+	}}
+
+	Languages("", "en")(okHandler).ServeHTTP(w, r)
+
+	if want, got := http.StatusNotAcceptable, w.Code; want != got {
+		t.Fatalf("want status %d, got %d", want, got)
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright (c) 2015, SoundCloud Ltd.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the README file.
+// Source code and contact info at http://github.com/streadway/handy
+
+package accept
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// mediaRange is a single element of an Accept header or a media type offered
+// by the server.
+type mediaRange struct {
+	typ     string
+	subtype string
+	params  map[string]string
+	q       float64
+}
+
+// parseMediaRange parses a media type or range with optional parameters.  The
+// quality parameter "q" and any accept extensions following it are removed
+// from params and stored in q, defaulting to 1.
+func parseMediaRange(s string) (mediaRange, bool) {
+	s = strings.TrimSpace(s)
+	if s == "*" {
+		// Some clients send a bare "*" to mean "*/*".
+		s = ALL
+	}
+
+	// Accept extensions follow the quality parameter, strip them so they are
+	// not mistaken for media type parameters.
+	var q string
+	if i := qualityIndex(s); i >= 0 {
+		s, q = s[:i], s[i+1:]
+	}
+
+	mediaType, params, err := mime.ParseMediaType(s)
+	if err != nil {
+		return mediaRange{}, false
+	}
+
+	slash := strings.IndexByte(mediaType, '/')
+	if slash <= 0 || slash == len(mediaType)-1 {
+		return mediaRange{}, false
+	}
+
+	r := mediaRange{
+		typ:     mediaType[:slash],
+		subtype: mediaType[slash+1:],
+		params:  params,
+		q:       1,
+	}
+
+	if r.typ == "*" && r.subtype != "*" {
+		return mediaRange{}, false
+	}
+
+	if q != "" {
+		quality, ok := parseQuality(q)
+		if !ok {
+			return mediaRange{}, false
+		}
+		r.q = quality
+	}
+
+	return r, true
+}
+
+// qualityIndex returns the index of the ';' that starts the "q" parameter in
+// s, or -1 when there is none.
+func qualityIndex(s string) int {
+	for i := strings.IndexByte(s, ';'); i >= 0; {
+		rest := strings.TrimLeft(s[i+1:], " \t")
+		if len(rest) > 0 && (rest[0] == 'q' || rest[0] == 'Q') &&
+			strings.HasPrefix(strings.TrimLeft(rest[1:], " \t"), "=") {
+			return i
+		}
+
+		next := strings.IndexByte(s[i+1:], ';')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return -1
+}
+
+// parseQuality parses the "q=value" parameter and any accept extensions
+// following it, returning the weight between 0 and 1.
+func parseQuality(s string) (float64, bool) {
+	if i := strings.IndexByte(s, ';'); i >= 0 {
+		s = s[:i]
+	}
+
+	eq := strings.IndexByte(s, '=')
+	if eq < 0 {
+		return 0, false
+	}
+
+	q, err := strconv.ParseFloat(strings.TrimSpace(s[eq+1:]), 64)
+	if err != nil || q < 0 || q > 1 {
+		return 0, false
+	}
+	return q, true
+}
+
+// parseAccept splits an Accept header into its media ranges, skipping any
+// elements that cannot be parsed.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, a := range strings.Split(accept, ",") {
+		if r, ok := parseMediaRange(a); ok {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+// String formats the media type without the quality parameter.
+func (r mediaRange) String() string {
+	return mime.FormatMediaType(r.typ+"/"+r.subtype, r.params)
+}
+
+// wildcard reports whether the range stands for more than one media type,
+// like "*/*", "text/*" or "application/*+json".
+func (r mediaRange) wildcard() bool {
+	return r.typ == "*" || r.subtype == "*" || strings.HasPrefix(r.subtype, "*+")
+}
+
+// base returns the media type of a structured syntax suffix range like
+// "application/json" for "application/*+json".  The second result is false
+// for other ranges.
+func (r mediaRange) base() (string, bool) {
+	if r.typ == "*" || !strings.HasPrefix(r.subtype, "*+") {
+		return "", false
+	}
+	return mediaRange{typ: r.typ, subtype: r.subtype[2:], params: r.params}.String(), true
+}
+
+// suffix returns the structured syntax suffix of a subtype, like "json" for
+// "vnd.api+json", or "" when there is none.
+// https://tools.ietf.org/html/rfc6839
+func suffix(subtype string) string {
+	if i := strings.LastIndexByte(subtype, '+'); i >= 0 {
+		return subtype[i+1:]
+	}
+	return ""
+}
+
+// Precedence of a range matching a media type, more specific ranges take
+// precedence over less specific ones.
+const (
+	noMatch = iota - 1
+	matchAll
+	matchSuffix
+	matchType
+	matchExact
+)
+
+// match returns the precedence with which this range matches the offered
+// media type, or noMatch.  A range with a structured syntax suffix like
+// "application/problem+json" matches the offer "application/json" with more
+// precedence than "*/*", but less than "application/*" as it names a
+// different media type.  Only the parameters the offer declares are compared,
+// so "application/json; charset=utf-8" matches the offer "application/json",
+// and each of them adds to the precedence of an exact range.
+func (r mediaRange) match(offer mediaRange) int {
+	if r.typ == "*" {
+		return matchAll
+	}
+
+	if r.typ != offer.typ {
+		return noMatch
+	}
+
+	switch r.subtype {
+	case "*":
+		return matchType
+	case offer.subtype:
+		p := matchExact
+		for k, v := range r.params {
+			if o, ok := offer.params[k]; ok {
+				if !strings.EqualFold(o, v) {
+					return noMatch
+				}
+				p++
+			}
+		}
+		return p
+	}
+
+	if s := suffix(r.subtype); s != "" && s == offer.subtype {
+		return matchSuffix
+	}
+
+	return noMatch
+}
+
+// contains reports whether the wildcard offer includes the concrete media
+// type of the range.
+func (r mediaRange) contains(other mediaRange) bool {
+	if r.typ != "*" && r.typ != other.typ {
+		return false
+	}
+
+	switch {
+	case r.typ == "*", r.subtype == "*":
+		return true
+	case strings.HasPrefix(r.subtype, "*+"):
+		return suffix(other.subtype) == r.subtype[2:]
+	}
+
+	return r.subtype == other.subtype
+}
+
+// quality returns the weight the client assigns to the offered media type,
+// taken from the most specific range matching it.  Ranges matching by their
+// structured syntax suffix only make the offer acceptable, they never lower
+// the weight given by "*/*".  The second result is false if no range matches.
+func quality(ranges []mediaRange, offer mediaRange) (float64, bool) {
+	best := noMatch
+	q := 0.0
+	suffixed := false
+	suffixQ := 0.0
+	for _, r := range ranges {
+		p := r.match(offer)
+		if p == matchSuffix {
+			if !suffixed || r.q > suffixQ {
+				suffixed, suffixQ = true, r.q
+			}
+			continue
+		}
+		if p > best {
+			best, q = p, r.q
+		}
+	}
+
+	if suffixed && best < matchSuffix && (best == noMatch || suffixQ > q) {
+		best, q = matchSuffix, suffixQ
+	}
+
+	return q, best > noMatch
+}
+
+// Negotiate selects the media type from offers that best satisfies the Accept
+// header following https://tools.ietf.org/html/rfc7231#section-5.3.2.  Offers
+// are weighted by the quality of the most specific matching range, ties are
+// broken by the order of the offers.  Offers the client rejects with "q=0"
+// are never selected.
+//
+// Offers may be ranges like "text/*" or "application/*+json", in which case
+// the most preferred media type from the Accept header within that range is
+// selected.  Structured syntax suffixes are understood, so a client accepting
+// "application/vnd.api+json" accepts the offer "application/json".
+//
+// An empty Accept header accepts any media type, so the first offer is
+// returned.  The second result is false when no offer is acceptable.
+func Negotiate(accept string, offers ...string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		// The absense of an Accept header is equivalent to "*/*".
+		// https://tools.ietf.org/html/rfc7231#section-5.3.2
+		if len(offers) == 0 {
+			return "", true
+		}
+		return offers[0], true
+	}
+
+	return negotiate(parseAccept(accept), offers)
+}
+
+// negotiate selects the offer with the highest quality in ranges.
+func negotiate(ranges []mediaRange, offers []string) (string, bool) {
+	if len(offers) == 0 {
+		// Without offers, only clients accepting any media type are satisfied.
+		q, ok := quality(ranges, mediaRange{typ: "*", subtype: "*"})
+		return "", ok && q > 0
+	}
+
+	var (
+		best  string
+		bestQ float64
+	)
+
+	consider := func(mediaType string, q float64, ok bool) {
+		if ok && q > bestQ {
+			best, bestQ = mediaType, q
+		}
+	}
+
+	for _, o := range offers {
+		offer, ok := parseMediaRange(o)
+		if !ok {
+			continue
+		}
+
+		if offer.wildcard() {
+			// Prefer the concrete media types the client asks for.
+			for _, r := range ranges {
+				if !r.wildcard() && offer.contains(r) {
+					q, ok := quality(ranges, r)
+					consider(r.String(), q, ok)
+				}
+			}
+		}
+
+		q, ok := quality(ranges, offer)
+		consider(o, q, ok)
+	}
+
+	return best, bestQ > 0
+}
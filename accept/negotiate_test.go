@@ -0,0 +1,218 @@
+// Copyright (c) 2015, SoundCloud Ltd.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the README file.
+// Source code and contact info at http://github.com/streadway/handy
+
+package accept
+
+import (
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		offers []string
+		want   string
+		ok     bool
+	}{
+		{
+			accept: "",
+			offers: []string{"application/json", "text/html"},
+			want:   "application/json",
+			ok:     true,
+		},
+		{
+			accept: "text/html, application/json",
+			offers: []string{"application/json", "text/html"},
+			want:   "application/json",
+			ok:     true,
+		},
+		{
+			accept: "text/html, application/json;q=0.9",
+			offers: []string{"application/json", "text/html"},
+			want:   "text/html",
+			ok:     true,
+		},
+		{
+			accept: "application/json;q=0",
+			offers: []string{"application/json"},
+			ok:     false,
+		},
+		{
+			accept: "*/*, application/json;q=0",
+			offers: []string{"application/json", "text/html"},
+			want:   "text/html",
+			ok:     true,
+		},
+		{
+			accept: "*/*;q=0.1, text/plain",
+			offers: []string{"application/json", "text/plain"},
+			want:   "text/plain",
+			ok:     true,
+		},
+		{
+			accept: "*",
+			offers: []string{"text/plain"},
+			want:   "text/plain",
+			ok:     true,
+		},
+		{
+			accept: "text/html;level=1, text/html;q=0.5, application/json;q=0.7",
+			offers: []string{"text/html;level=2", "application/json"},
+			want:   "application/json",
+			ok:     true,
+		},
+		{
+			accept: "application/json; charset=utf-8",
+			offers: []string{"application/json", "application/javascript"},
+			want:   "application/json",
+			ok:     true,
+		},
+		{
+			accept: "text/html;q=0.8;ext=1, application/json;q=0.7",
+			offers: []string{"application/json", "text/html"},
+			want:   "text/html",
+			ok:     true,
+		},
+		{
+			accept: "application/json;q=2, text/plain",
+			offers: []string{"application/json"},
+			ok:     false,
+		},
+		{
+			accept: "text/*",
+			offers: []string{"application/json", "text/html"},
+			want:   "text/html",
+			ok:     true,
+		},
+		{
+			accept: "application/*",
+			offers: []string{"application/json"},
+			want:   "application/json",
+			ok:     true,
+		},
+		{
+			accept: "text/*, text/plain;q=0",
+			offers: []string{"text/plain"},
+			ok:     false,
+		},
+		{
+			accept: "text/plain;q=0.5, text/event-stream",
+			offers: []string{"text/*"},
+			want:   "text/event-stream",
+			ok:     true,
+		},
+		{
+			accept: "text/*;q=0.5, text/html;q=0",
+			offers: []string{"text/*"},
+			want:   "text/*",
+			ok:     true,
+		},
+		{
+			accept: "application/problem+json",
+			offers: []string{"application/json"},
+			want:   "application/json",
+			ok:     true,
+		},
+		{
+			accept: "application/problem+json;q=0, */*",
+			offers: []string{"application/json", "application/javascript"},
+			want:   "application/json",
+			ok:     true,
+		},
+		{
+			accept: "application/problem+json;q=0.2, application/vnd.api+json, */*;q=0.5",
+			offers: []string{"text/plain", "application/json"},
+			want:   "application/json",
+			ok:     true,
+		},
+		{
+			accept: "application/vnd.api+json, application/json;q=0.5",
+			offers: []string{"application/*+json"},
+			want:   "application/vnd.api+json",
+			ok:     true,
+		},
+		{
+			accept: "*/*;q=0.1, application/atom+xml",
+			offers: []string{"text/plain", "application/xml"},
+			want:   "application/xml",
+			ok:     true,
+		},
+		{
+			accept: "application/*, application/vnd.api+json;q=0",
+			offers: []string{"application/json"},
+			want:   "application/json",
+			ok:     true,
+		},
+		{
+			accept: "application/vnd.api+json;q=0, application/*",
+			offers: []string{"application/*+json"},
+			want:   "application/*+json",
+			ok:     true,
+		},
+		{
+			accept: "image/svg+xml",
+			offers: []string{"application/xml"},
+			ok:     false,
+		},
+		{
+			accept: "invalid, application/json",
+			offers: []string{"application/json"},
+			want:   "application/json",
+			ok:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		got, ok := Negotiate(tt.accept, tt.offers...)
+		if ok != tt.ok {
+			t.Errorf("%q with %v want ok %t, got %t", tt.accept, tt.offers, tt.ok, ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q with %v want %q, got %q", tt.accept, tt.offers, tt.want, got)
+		}
+	}
+}
+
+func TestParseMediaRangeQuality(t *testing.T) {
+	tests := []struct {
+		in string
+		q  float64
+	}{
+		{"text/plain", 1},
+		{"text/plain;q=0.5", 0.5},
+		{"text/plain; Q=0.25", 0.25},
+		{"text/plain;format=flowed;q=0", 0},
+		{"text/plain;q=0.3;ext=token", 0.3},
+	}
+
+	for _, tt := range tests {
+		r, ok := parseMediaRange(tt.in)
+		if !ok {
+			t.Fatalf("%q want to parse", tt.in)
+		}
+		if r.q != tt.q {
+			t.Errorf("%q want q %v, got %v", tt.in, tt.q, r.q)
+		}
+		if _, ok := r.params["q"]; ok {
+			t.Errorf("%q want q removed from params", tt.in)
+		}
+	}
+}
+
+func TestMediaRangeMatchPrecedence(t *testing.T) {
+	offer, _ := parseMediaRange("application/json")
+
+	ranges := []string{"*/*", "application/problem+json", "application/*", "application/json"}
+	last := noMatch
+	for _, in := range ranges {
+		r, _ := parseMediaRange(in)
+		p := r.match(offer)
+		if p <= last {
+			t.Fatalf("want %q to take precedence over the previous range", in)
+		}
+		last = p
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2015, SoundCloud Ltd.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the README file.
+// Source code and contact info at http://github.com/streadway/handy
+
+package accept
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ErrNotAcceptable is returned by Render when no registered encoder produces
+// a media type acceptable to the client.
+var ErrNotAcceptable = errors.New("accept: no acceptable encoder")
+
+// EncodeFunc serializes v to w in the media type it is registered for.
+type EncodeFunc func(w io.Writer, v interface{}) error
+
+// EncodeJSON serializes v as JSON.
+func EncodeJSON(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// EncodeXML serializes v as XML.
+func EncodeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(v)
+}
+
+// EncodePlain formats v in its default format like fmt.Print.
+func EncodePlain(w io.Writer, v interface{}) error {
+	_, err := fmt.Fprint(w, v)
+	return err
+}
+
+// Encoders is a registry of encoders by the media type they produce.
+type Encoders struct {
+	mu         sync.RWMutex
+	mediaTypes []string
+	encoders   map[string]EncodeFunc
+	ranges     map[string]mediaRange
+}
+
+// NewEncoders returns an empty registry of encoders.
+func NewEncoders() *Encoders {
+	return &Encoders{
+		encoders: make(map[string]EncodeFunc),
+		ranges:   make(map[string]mediaRange),
+	}
+}
+
+// DefaultEncoders is the registry used by Render, preferring JSON over XML
+// over plain text.
+var DefaultEncoders = NewEncoders()
+
+func init() {
+	DefaultEncoders.Register("application/json", EncodeJSON)
+	DefaultEncoders.Register("application/xml", EncodeXML)
+	DefaultEncoders.Register("text/plain; charset=utf-8", EncodePlain)
+}
+
+// Register adds the encoder for the media type, which may be a range like
+// "application/*+json".  Media types are preferred in the order they are
+// first registered, registering a media type again replaces its encoder.
+//
+// A range encodes the media types within it the client names.  Clients only
+// accepting it through a wildcard like "*/*" get the base media type of a
+// structured syntax suffix range, like "application/json", and other ranges
+// are skipped, as a range is never a valid Content-Type.
+func (e *Encoders) Register(mediaType string, enc EncodeFunc) {
+	r, ok := parseMediaRange(mediaType)
+	if !ok {
+		panic("accept: invalid media type " + mediaType)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.encoders[mediaType]; !ok {
+		e.mediaTypes = append(e.mediaTypes, mediaType)
+	}
+	e.encoders[mediaType] = enc
+	e.ranges[mediaType] = r
+}
+
+// lookup negotiates the media type and its encoder for the request.  A media
+// type already negotiated by Middleware is used when an encoder produces it.
+func (e *Encoders) lookup(r *http.Request) (string, EncodeFunc, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if mediaType, ok := MediaType(r.Context()); ok {
+		if enc, ok := e.encoder(mediaType); ok {
+			return mediaType, enc, true
+		}
+	}
+
+	accept := r.Header.Get("Accept")
+	offers := e.mediaTypes
+
+	for {
+		mediaType, ok := Negotiate(accept, offers...)
+		if !ok {
+			return "", nil, false
+		}
+
+		m, _ := parseMediaRange(mediaType)
+		if !m.wildcard() {
+			enc, ok := e.encoder(mediaType)
+			return mediaType, enc, ok
+		}
+
+		// A range is never written as the Content-Type, resolve it when
+		// the client accepts its base type, otherwise try the next offer.
+		if base, ok := m.base(); ok {
+			if _, ok := Negotiate(accept, base); ok {
+				return base, e.encoders[mediaType], true
+			}
+		}
+
+		offers = without(offers, mediaType)
+	}
+}
+
+// without returns the media types except mediaType.
+func without(mediaTypes []string, mediaType string) []string {
+	rest := make([]string, 0, len(mediaTypes))
+	for _, t := range mediaTypes {
+		if t != mediaType {
+			rest = append(rest, t)
+		}
+	}
+	return rest
+}
+
+// encoder returns the encoder registered for the media type or for a range
+// including it.
+func (e *Encoders) encoder(mediaType string) (EncodeFunc, bool) {
+	if enc, ok := e.encoders[mediaType]; ok {
+		return enc, true
+	}
+
+	c, ok := parseMediaRange(mediaType)
+	if !ok || c.wildcard() {
+		return nil, false
+	}
+
+	for _, t := range e.mediaTypes {
+		if e.ranges[t].includes(c) {
+			return e.encoders[t], true
+		}
+	}
+
+	return nil, false
+}
+
+// Render serializes v in the media type preferred by the request and writes
+// it with the status code.  When no encoder is acceptable it responds with
+// "406 Not Acceptable" and returns ErrNotAcceptable.  Encoding errors are
+// returned before anything is written so the caller can respond otherwise.
+func (e *Encoders) Render(w http.ResponseWriter, r *http.Request, code int, v interface{}) error {
+	w.Header().Add("Vary", "Accept")
+
+	mediaType, enc, ok := e.lookup(r)
+	if !ok {
+		w.WriteHeader(http.StatusNotAcceptable)
+		return ErrNotAcceptable
+	}
+
+	var body bytes.Buffer
+	if err := enc(&body, v); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(code)
+	_, err := body.WriteTo(w)
+	return err
+}
+
+// Render serializes v with the DefaultEncoders.
+func Render(w http.ResponseWriter, r *http.Request, code int, v interface{}) error {
+	return DefaultEncoders.Render(w, r, code, v)
+}
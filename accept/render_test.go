@@ -0,0 +1,159 @@
+// Copyright (c) 2015, SoundCloud Ltd.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the README file.
+// Source code and contact info at http://github.com/streadway/handy
+
+package accept
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type greeting struct {
+	Hello string `json:"hello" xml:"hello"`
+}
+
+func (g greeting) String() string { return "hello " + g.Hello }
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		accept      string
+		code        int
+		contentType string
+		body        string
+	}{
+		{
+			accept:      "",
+			code:        http.StatusCreated,
+			contentType: "application/json",
+			body:        `{"hello":"world"}` + "\n",
+		},
+		{
+			accept:      "application/xml",
+			code:        http.StatusCreated,
+			contentType: "application/xml",
+			body:        `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<greeting><hello>world</hello></greeting>`,
+		},
+		{
+			accept:      "text/*",
+			code:        http.StatusCreated,
+			contentType: "text/plain; charset=utf-8",
+			body:        "hello world",
+		},
+		{
+			accept:      "application/problem+json",
+			code:        http.StatusCreated,
+			contentType: "application/json",
+			body:        `{"hello":"world"}` + "\n",
+		},
+		{
+			accept: "image/png",
+			code:   http.StatusNotAcceptable,
+		},
+	}
+
+	for _, tt := range tests {
+		w, r := httptest.NewRecorder(), newRequest(tt.accept)
+		err := Render(w, r, http.StatusCreated, greeting{"world"})
+
+		if want, got := tt.code, w.Code; want != got {
+			t.Errorf("%q want status %d, got %d", tt.accept, want, got)
+		}
+		if tt.code == http.StatusNotAcceptable {
+			if err != ErrNotAcceptable {
+				t.Errorf("%q want ErrNotAcceptable, got %v", tt.accept, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q want no error, got %v", tt.accept, err)
+		}
+		if want, got := tt.contentType, w.Header().Get("Content-Type"); want != got {
+			t.Errorf("%q want Content-Type %q, got %q", tt.accept, want, got)
+		}
+		if want, got := tt.body, w.Body.String(); want != got {
+			t.Errorf("%q want body %q, got %q", tt.accept, want, got)
+		}
+	}
+}
+
+func TestRenderNegotiatedByMiddleware(t *testing.T) {
+	h := XML(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Render(w, r, http.StatusOK, greeting{"world"})
+	}))
+
+	w, r := httptest.NewRecorder(), newRequest("application/json;q=0.5, application/xml")
+	h.ServeHTTP(w, r)
+
+	if want, got := "application/xml", w.Header().Get("Content-Type"); want != got {
+		t.Fatalf("want Content-Type %q, got %q", want, got)
+	}
+}
+
+func TestEncodersRegister(t *testing.T) {
+	encoders := NewEncoders()
+	encoders.Register("application/*+json", EncodeJSON)
+	encoders.Register("text/csv", func(w io.Writer, v interface{}) error {
+		_, err := io.WriteString(w, "hello\nworld\n")
+		return err
+	})
+
+	w, r := httptest.NewRecorder(), newRequest("text/csv, application/vnd.api+json")
+	if err := encoders.Render(w, r, http.StatusOK, greeting{"world"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := "application/vnd.api+json", w.Header().Get("Content-Type"); want != got {
+		t.Fatalf("want Content-Type %q, got %q", want, got)
+	}
+}
+
+func TestEncodersNeverWriteRanges(t *testing.T) {
+	tests := []struct {
+		accept      string
+		contentType string
+	}{
+		{"*/*", "application/json"},
+		{"", "application/json"},
+		{"text/*", "text/csv"},
+		{"*/*, application/json;q=0", "text/csv"},
+		{"application/problem+json, */*;q=0.1", "application/problem+json"},
+	}
+
+	for _, test := range tests {
+		encoders := NewEncoders()
+		encoders.Register("application/*+json", EncodeJSON)
+		encoders.Register("text/*", EncodePlain)
+		encoders.Register("text/csv", EncodePlain)
+
+		w, r := httptest.NewRecorder(), newRequest(test.accept)
+		if err := encoders.Render(w, r, http.StatusOK, greeting{"world"}); err != nil {
+			t.Errorf("%q: %v", test.accept, err)
+			continue
+		}
+
+		if want, got := test.contentType, w.Header().Get("Content-Type"); want != got {
+			t.Errorf("%q: want Content-Type %q, got %q", test.accept, want, got)
+		}
+	}
+}
+
+func TestRenderEncodeError(t *testing.T) {
+	encoders := NewEncoders()
+	encoders.Register("text/plain", func(io.Writer, interface{}) error {
+		return errors.New("boom")
+	})
+
+	w, r := httptest.NewRecorder(), newRequest("text/plain")
+	if err := encoders.Render(w, r, http.StatusOK, nil); err == nil {
+		t.Fatal("want encoding error")
+	}
+
+	if w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+		t.Fatal("want nothing written on encoding error")
+	}
+}
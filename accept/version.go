@@ -0,0 +1,183 @@
+// Copyright (c) 2015, SoundCloud Ltd.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the README file.
+// Source code and contact info at http://github.com/streadway/handy
+
+package accept
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Versions parameterizes the dispatch of requests by the version of a vendor
+// media type.
+type Versions struct {
+	// Vendor is the unversioned vendor media type, like
+	// "application/vnd.acme+json".
+	Vendor string
+
+	// Handlers serve each version of the vendor media type.  The version "2"
+	// is requested with either "application/vnd.acme.v2+json" or
+	// "application/vnd.acme+json; version=2".
+	Handlers map[string]http.Handler
+
+	// Default is the version served to clients accepting the unversioned
+	// vendor media type, its structured syntax suffix like "application/json"
+	// or any media type.  Without a default, clients must request a version.
+	Default string
+}
+
+// Version returns the version negotiated by Versioned for the request
+// context.  The second result is false when no version was negotiated.
+func Version(ctx context.Context) (string, bool) {
+	version, ok := ctx.Value(versionKey).(string)
+	return version, ok
+}
+
+// Versioned returns a handler that negotiates the version of the vendor media
+// type from the Accept header and dispatches to the handler of that version.
+// The negotiated media type and version are available to the handler through
+// MediaType and Version.
+//
+// Requests for versions without a handler or for other media types are
+// answered with "406 Not Acceptable" listing the available versions.
+func Versioned(cfg Versions) http.Handler {
+	vendor, ok := parseMediaRange(cfg.Vendor)
+	if !ok || vendor.wildcard() {
+		panic("accept: invalid vendor media type " + cfg.Vendor)
+	}
+
+	if _, ok := cfg.Handlers[cfg.Default]; cfg.Default != "" && !ok {
+		panic("accept: no handler for default version " + cfg.Default)
+	}
+
+	base, sfx := vendor.subtype, suffix(vendor.subtype)
+	if sfx != "" {
+		base = strings.TrimSuffix(base, "+"+sfx)
+	}
+
+	versioned := func(version string) string {
+		subtype := base + ".v" + version
+		if sfx != "" {
+			subtype += "+" + sfx
+		}
+		return vendor.typ + "/" + subtype
+	}
+
+	var others []string
+	for version := range cfg.Handlers {
+		if version != cfg.Default {
+			others = append(others, version)
+		}
+	}
+	sort.Strings(others)
+
+	// Offers are ordered by preference, so the default version wins ties.
+	var (
+		offers    []string
+		available []string
+		versions  = make(map[string]string)
+	)
+
+	offer := func(mediaType, version string) {
+		offers = append(offers, mediaType)
+		versions[mediaType] = version
+	}
+
+	if cfg.Default != "" {
+		others = append([]string{cfg.Default}, others...)
+	}
+
+	for _, version := range others {
+		offer(versioned(version), version)
+		offer(mime.FormatMediaType(cfg.Vendor, map[string]string{"version": version}), version)
+		available = append(available, versioned(version))
+	}
+
+	// Fallbacks come last so they don't take precedence over the versions
+	// they match by structured syntax suffix.
+	if cfg.Default != "" {
+		offer(cfg.Vendor, cfg.Default)
+		if sfx != "" {
+			offer(vendor.typ+"/"+sfx, cfg.Default)
+		}
+	}
+
+	// unknown reports whether the range requests a version of the vendor
+	// media type that has no handler.  These ranges are ignored so they don't
+	// match the structured syntax suffix of the default version.
+	unknown := func(r mediaRange) bool {
+		if r.typ != vendor.typ {
+			return false
+		}
+
+		if r.subtype == vendor.subtype {
+			version, ok := r.params["version"]
+			return ok && cfg.Handlers[version] == nil
+		}
+
+		version := r.subtype
+		if sfx != "" {
+			if suffix(version) != sfx {
+				return false
+			}
+			version = strings.TrimSuffix(version, "+"+sfx)
+		}
+
+		if !strings.HasPrefix(version, base+".v") {
+			return false
+		}
+		return cfg.Handlers[strings.TrimPrefix(version, base+".v")] == nil
+	}
+
+	// explicit reports whether the range requests a specific version, without
+	// a default only these ranges are served.
+	explicit := func(r mediaRange) bool {
+		if r.wildcard() {
+			return false
+		}
+		_, ok := r.params["version"]
+		return r.subtype != vendor.subtype || ok
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+
+		var ranges []mediaRange
+		if accept := r.Header.Get("Accept"); strings.TrimSpace(accept) == "" {
+			// The absense of an Accept header is equivalent to "*/*".
+			ranges = []mediaRange{{typ: "*", subtype: "*", q: 1}}
+		} else {
+			ranges = parseAccept(accept)
+		}
+
+		n := 0
+		for _, mr := range ranges {
+			if unknown(mr) || cfg.Default == "" && !explicit(mr) {
+				continue
+			}
+			ranges[n] = mr
+			n++
+		}
+
+		mediaType, ok := negotiate(ranges[:n], offers)
+		if !ok {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(http.StatusNotAcceptable)
+			for _, a := range available {
+				fmt.Fprintln(w, a)
+			}
+			return
+		}
+
+		version := versions[mediaType]
+		ctx := context.WithValue(r.Context(), mediaTypeKey, mediaType)
+		ctx = context.WithValue(ctx, versionKey, version)
+		cfg.Handlers[version].ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2015, SoundCloud Ltd.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the README file.
+// Source code and contact info at http://github.com/streadway/handy
+
+package accept
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type versionHandler string
+
+func (h versionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	version, _ := Version(r.Context())
+	mediaType, _ := MediaType(r.Context())
+	w.Header().Set("Content-Type", mediaType)
+	w.Write([]byte(string(h) + ":" + version))
+}
+
+func TestVersioned(t *testing.T) {
+	h := Versioned(Versions{
+		Vendor:  "application/vnd.acme+json",
+		Default: "2",
+		Handlers: map[string]http.Handler{
+			"1": versionHandler("one"),
+			"2": versionHandler("two"),
+			"3": versionHandler("three"),
+		},
+	})
+
+	tests := []struct {
+		accept    string
+		code      int
+		body      string
+		mediaType string
+	}{
+		{
+			accept:    "",
+			code:      http.StatusOK,
+			body:      "two:2",
+			mediaType: "application/vnd.acme.v2+json",
+		},
+		{
+			accept:    "application/vnd.acme.v1+json",
+			code:      http.StatusOK,
+			body:      "one:1",
+			mediaType: "application/vnd.acme.v1+json",
+		},
+		{
+			accept:    "application/vnd.acme+json; version=3",
+			code:      http.StatusOK,
+			body:      "three:3",
+			mediaType: "application/vnd.acme+json; version=3",
+		},
+		{
+			accept:    "application/vnd.acme+json",
+			code:      http.StatusOK,
+			body:      "two:2",
+			mediaType: "application/vnd.acme+json; version=2",
+		},
+		{
+			accept:    "application/json",
+			code:      http.StatusOK,
+			body:      "two:2",
+			mediaType: "application/json",
+		},
+		{
+			accept:    "application/vnd.acme.v1+json;q=0.5, application/vnd.acme.v3+json",
+			code:      http.StatusOK,
+			body:      "three:3",
+			mediaType: "application/vnd.acme.v3+json",
+		},
+		{
+			accept: "application/vnd.acme.v4+json",
+			code:   http.StatusNotAcceptable,
+		},
+		{
+			accept: "application/vnd.acme+json; version=4",
+			code:   http.StatusNotAcceptable,
+		},
+		{
+			accept: "text/html",
+			code:   http.StatusNotAcceptable,
+		},
+	}
+
+	for _, tt := range tests {
+		w, r := httptest.NewRecorder(), newRequest(tt.accept)
+		h.ServeHTTP(w, r)
+
+		if want, got := tt.code, w.Code; want != got {
+			t.Errorf("%q want status %d, got %d", tt.accept, want, got)
+			continue
+		}
+
+		if tt.code != http.StatusOK {
+			for _, v := range []string{"v1", "v2", "v3"} {
+				if body := w.Body.String(); !strings.Contains(body, "application/vnd.acme."+v+"+json") {
+					t.Errorf("%q want available %s in body, got %q", tt.accept, v, body)
+				}
+			}
+			continue
+		}
+
+		if want, got := tt.body, w.Body.String(); want != got {
+			t.Errorf("%q want body %q, got %q", tt.accept, want, got)
+		}
+		if want, got := tt.mediaType, w.Header().Get("Content-Type"); want != got {
+			t.Errorf("%q want media type %q, got %q", tt.accept, want, got)
+		}
+	}
+}
+
+func TestVersionedWithoutDefault(t *testing.T) {
+	h := Versioned(Versions{
+		Vendor: "application/vnd.acme+json",
+		Handlers: map[string]http.Handler{
+			"1": versionHandler("one"),
+		},
+	})
+
+	tests := []struct {
+		accept string
+		code   int
+	}{
+		{"", http.StatusNotAcceptable},
+		{"*/*", http.StatusNotAcceptable},
+		{"application/json", http.StatusNotAcceptable},
+		{"application/vnd.acme+json", http.StatusNotAcceptable},
+		{"application/vnd.acme.v1+json", http.StatusOK},
+		{"application/vnd.acme+json;version=1", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		w, r := httptest.NewRecorder(), newRequest(tt.accept)
+		h.ServeHTTP(w, r)
+
+		if want, got := tt.code, w.Code; want != got {
+			t.Errorf("%q want status %d, got %d", tt.accept, want, got)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package breaker
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Admin is an http.Handler to inspect and operate named breakers.
+//
+// GET lists the breakers as JSON with their state, the summary of their
+// window and the time until an open circuit allows probes, as far as each
+// breaker reports them like Circuit does.
+//
+// POST with the form values "name" and "action" set to "trip" or "reset"
+// manually opens or closes the named breaker and responds with its state.
+type Admin struct {
+	mu         sync.Mutex
+	breakers   map[string]Breaker
+	registries []*Registry
+}
+
+// NewAdmin constructs an Admin without breakers.
+func NewAdmin() *Admin {
+	return &Admin{breakers: make(map[string]Breaker)}
+}
+
+// Register adds the breaker under name, replacing any breaker registered
+// under the same name.
+func (a *Admin) Register(name string, b Breaker) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.breakers[name] = b
+}
+
+// RegisterRegistry adds the breakers of the registry under their keys.
+// Breakers added with Register take precedence over those of a registry
+// with the same name.
+func (a *Admin) RegisterRegistry(r *Registry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.registries = append(a.registries, r)
+}
+
+// AdminStatus is the JSON representation of a breaker served by Admin.
+type AdminStatus struct {
+	Name         string  `json:"name"`
+	State        string  `json:"state,omitempty"`
+	Total        uint    `json:"total"`
+	Errors       uint    `json:"errors"`
+	Rate         float64 `json:"rate"`
+	HalfOpenInMs int64   `json:"half_open_in_ms"`
+}
+
+// all returns the breakers by name.
+func (a *Admin) all() map[string]Breaker {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	all := make(map[string]Breaker, len(a.breakers))
+	for _, r := range a.registries {
+		r.Each(func(key string, b Breaker) { all[key] = b })
+	}
+	for name, b := range a.breakers {
+		all[name] = b
+	}
+	return all
+}
+
+func adminStatus(name string, b Breaker) AdminStatus {
+	s := AdminStatus{Name: name}
+
+	if b, ok := b.(interface{ State() State }); ok {
+		s.State = b.State().String()
+	}
+
+	if b, ok := b.(interface{ Stats() Summary }); ok {
+		sum := b.Stats()
+		s.Total, s.Errors, s.Rate = sum.Total, sum.Errors, sum.Rate
+	}
+
+	if b, ok := b.(interface{ RetryAfter() time.Duration }); ok {
+		s.HalfOpenInMs = int64(b.RetryAfter() / time.Millisecond)
+	}
+
+	return s
+}
+
+func (a *Admin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET", "HEAD":
+		a.serveList(w, r)
+	case "POST":
+		a.serveAction(w, r)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *Admin) serveList(w http.ResponseWriter, r *http.Request) {
+	all := a.all()
+
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]AdminStatus, 0, len(names))
+	for _, name := range names {
+		list = append(list, adminStatus(name, all[name]))
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (a *Admin) serveAction(w http.ResponseWriter, r *http.Request) {
+	name := r.FormValue("name")
+	b, ok := a.all()[name]
+	if !ok {
+		http.Error(w, "unknown breaker "+name, http.StatusNotFound)
+		return
+	}
+
+	op, ok := b.(interface {
+		Trip()
+		Reset()
+	})
+	if !ok {
+		http.Error(w, "breaker "+name+" cannot be operated", http.StatusNotImplemented)
+		return
+	}
+
+	switch action := r.FormValue("action"); action {
+	case "trip":
+		op.Trip()
+	case "reset":
+		op.Reset()
+	default:
+		http.Error(w, "unknown action "+action, http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, adminStatus(name, b))
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
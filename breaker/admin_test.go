@@ -0,0 +1,119 @@
+package breaker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAdminListsBreakersByName(t *testing.T) {
+	r := NewRegistry(func(string) Breaker { return NewSync(Config{}) }, 0)
+	r.Get("upstream").Success(0)
+
+	open := NewSync(Config{})
+	open.Trip()
+
+	a := NewAdmin()
+	a.Register("open", open)
+	a.Register("consecutive", NewConsecutive(ConsecutiveConfig{}))
+	a.RegisterRegistry(r)
+
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if want, got := http.StatusOK, w.Code; want != got {
+		t.Fatalf("want %d, got %d", want, got)
+	}
+
+	if want, got := "application/json", w.Header().Get("Content-Type"); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	var list []AdminStatus
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 3, len(list); want != got {
+		t.Fatalf("want %d breakers, got %d", want, got)
+	}
+
+	for i, want := range []AdminStatus{
+		{Name: "consecutive", State: "closed"},
+		{Name: "open", State: "open", HalfOpenInMs: list[1].HalfOpenInMs},
+		{Name: "upstream", State: "closed", Total: 1},
+	} {
+		if got := list[i]; want != got {
+			t.Errorf("%d: want %+v, got %+v", i, want, got)
+		}
+	}
+
+	if list[1].HalfOpenInMs <= 0 {
+		t.Errorf("want the remaining cooldown of the open breaker, got %d", list[1].HalfOpenInMs)
+	}
+}
+
+func TestAdminActions(t *testing.T) {
+	b := NewSync(Config{})
+	a := NewAdmin()
+	a.Register("b", b)
+
+	post := func(name, action string) *httptest.ResponseRecorder {
+		form := url.Values{"name": {name}, "action": {action}}
+		req := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, req)
+		return w
+	}
+
+	if want, got := http.StatusOK, post("b", "trip").Code; want != got {
+		t.Fatalf("trip: want %d, got %d", want, got)
+	}
+
+	if want, got := Open, b.State(); want != got {
+		t.Errorf("want %s after trip, got %s", want, got)
+	}
+
+	w := post("b", "reset")
+	if want, got := http.StatusOK, w.Code; want != got {
+		t.Fatalf("reset: want %d, got %d", want, got)
+	}
+
+	var status AdminStatus
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := "closed", status.State; want != got {
+		t.Errorf("want %q after reset, got %q", want, got)
+	}
+
+	for _, test := range []struct {
+		name, action string
+		code         int
+	}{
+		{"missing", "trip", http.StatusNotFound},
+		{"b", "explode", http.StatusBadRequest},
+	} {
+		if got := post(test.name, test.action).Code; test.code != got {
+			t.Errorf("%s %s: want %d, got %d", test.action, test.name, test.code, got)
+		}
+	}
+}
+
+func TestAdminRejectsOtherMethods(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewAdmin().ServeHTTP(w, httptest.NewRequest("DELETE", "/", nil))
+
+	if want, got := http.StatusMethodNotAllowed, w.Code; want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+
+	if want, got := "GET, HEAD, POST", w.Header().Get("Allow"); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
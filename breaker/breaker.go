@@ -1,9 +1,13 @@
 package breaker
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 // Breaker is an interface representing the ability to conditionally allow
-// requests to pass, and to report on the result of passed requests.
+// requests to pass, and to report on the result of passed requests.  Breakers
+// may also implement Ignorer to hear about requests ending without a result.
 type Breaker interface {
 	Allow() bool
 	Success(time.Duration)
@@ -11,19 +15,51 @@ type Breaker interface {
 }
 
 const (
-	// DefaultWindow is the default number of per-second buckets that will be
-	// considered when calculating metrics on the circuit breaker.
+	// DefaultWindow is the default period of DefaultBucket buckets that will
+	// be considered when calculating metrics on the circuit breaker.
 	DefaultWindow = 5 * time.Second
 
 	// DefaultCooldown is the default period a circuit will remain in the open
-	// state before allowing a single sentinel request through.
+	// state before allowing sentinel requests through.
 	DefaultCooldown = 1 * time.Second
 
+	// DefaultBucket is the default width of the buckets the window is split
+	// in.
+	DefaultBucket = 1 * time.Second
+
 	// DefaultMinObservations is the default number of observations that must
 	// be made before the circuit breaker
 	DefaultMinObservations = 10
 )
 
+// State is the observable state of a Circuit.
+type State int
+
+const (
+	// Closed allows requests and observes their results.
+	Closed State = iota
+
+	// Open rejects requests until the cooldown expires.
+	Open
+
+	// HalfOpen allows a limited number of requests to probe whether the
+	// circuit can close again.
+	HalfOpen
+)
+
+var stateNames = []string{
+	Closed:   "closed",
+	Open:     "open",
+	HalfOpen: "half-open",
+}
+
+func (s State) String() string {
+	if s < 0 || int(s) >= len(stateNames) {
+		return "unknown"
+	}
+	return stateNames[s]
+}
+
 type states int
 
 const (
@@ -35,27 +71,99 @@ const (
 	halfopen
 )
 
-type breaker struct {
-	force   chan states
+// public maps the internal states of the state machine, including the
+// transitional ones, to the observable State.
+func (s states) public() State {
+	switch s {
+	case tripped, open:
+		return Open
+	case halfopen:
+		return HalfOpen
+	}
+	return Closed
+}
+
+// status is a snapshot of the state machine.
+type status struct {
+	state      State
+	summary    Summary
+	retryAfter time.Duration
+}
+
+// Circuit is a Breaker that opens when the ratio of failures observed over a
+// window of time exceeds a threshold.  It is safe for concurrent use.
+type Circuit struct {
+	forced  chan states
+	inspect chan chan status
 	allow   chan bool
 	success chan time.Duration
 	failure chan time.Duration
+	ignored chan struct{}
+	done    chan struct{}
 
-	config breakerConfig
-}
-
-type breakerConfig struct {
-	FailureRatio float64 // normalized between 0.0 and 1.0
+	mu      sync.Mutex
+	config  Config
+	started bool
+	stopped bool
 
-	Window          time.Duration // number of second buckets to observe
-	Cooldown        time.Duration // time to wait before trying once when open
-	MinObservations uint          // observations required to open when failing
+	transitions notifier
+}
 
-	Now   func() time.Time                     // default time.Now
-	After func(time.Duration) <-chan time.Time // default time.After
+// Config parameterizes a Circuit.
+type Config struct {
+	// FailureRatio is the ratio of failures to observations over the window
+	// that opens the circuit, normalized between 0.0 and 1.0.
+	FailureRatio float64
+
+	// Window is the period observations are considered in, split in
+	// buckets.  Defaults to DefaultWindow.
+	Window time.Duration
+
+	// Bucket is the width of the buckets the window is split in, older
+	// observations expire a bucket at a time.  Narrow buckets like 100ms
+	// over a 2s window let the circuit react to recent failures within a
+	// few hundred milliseconds.  Defaults to DefaultBucket, or the Window
+	// when narrower.
+	Bucket time.Duration
+
+	// Cooldown is the time to wait before trying once when open, and for
+	// probes to report when half-open.  Defaults to DefaultCooldown.
+	Cooldown time.Duration
+
+	// CooldownPolicy overrides the time to wait when open depending on how
+	// many times the circuit tripped in a row, like ExponentialCooldown.
+	// Defaults to the constant Cooldown.
+	CooldownPolicy CooldownPolicy
+
+	// MinObservations is the number of observations in the window required
+	// before the circuit opens.
+	MinObservations uint
+
+	// HalfOpenProbes is the number of requests allowed concurrently to probe
+	// whether a half-open circuit can close.  Defaults to 1.
+	HalfOpenProbes uint
+
+	// HalfOpenSuccesses is the number of consecutive successful probes
+	// required to close a half-open circuit, a single failure opens it
+	// again.  Defaults to 1.
+	HalfOpenSuccesses uint
+
+	// SlowCallDuration is the duration from which successful and failed
+	// calls count as slow.  The circuit also opens when the ratio of slow
+	// calls in the window exceeds SlowCallRatio.  Zero disables slow calls.
+	SlowCallDuration time.Duration
+
+	// SlowCallRatio is the ratio of slow calls to observations over the
+	// window that opens the circuit, normalized between 0.0 and 1.0.
+	SlowCallRatio float64
+
+	// Now and After are the clock of the circuit, defaulting to time.Now and
+	// time.After.
+	Now   func() time.Time
+	After func(time.Duration) <-chan time.Time
 }
 
-func newBreaker(c breakerConfig) breaker {
+func (c Config) withDefaults() Config {
 	if c.FailureRatio < 0.0 {
 		c.FailureRatio = 0.0
 	}
@@ -64,14 +172,38 @@ func newBreaker(c breakerConfig) breaker {
 		c.FailureRatio = 1.0
 	}
 
+	if c.SlowCallRatio < 0.0 {
+		c.SlowCallRatio = 0.0
+	}
+
+	if c.SlowCallRatio > 1.0 {
+		c.SlowCallRatio = 1.0
+	}
+
 	if c.Window == 0 {
 		c.Window = DefaultWindow
 	}
 
+	if c.Bucket <= 0 {
+		c.Bucket = DefaultBucket
+	}
+
+	if c.Bucket > c.Window {
+		c.Bucket = c.Window
+	}
+
 	if c.Cooldown == 0 {
 		c.Cooldown = DefaultCooldown
 	}
 
+	if c.HalfOpenProbes == 0 {
+		c.HalfOpenProbes = 1
+	}
+
+	if c.HalfOpenSuccesses == 0 {
+		c.HalfOpenSuccesses = 1
+	}
+
 	if c.Now == nil {
 		c.Now = time.Now
 	}
@@ -80,47 +212,131 @@ func newBreaker(c breakerConfig) breaker {
 		c.After = time.After
 	}
 
-	b := breaker{
-		force:   make(chan states),
+	return c
+}
+
+// New constructs a new circuit breaker from the config, initially closed.
+// Unset durations and clocks take their defaults.
+func New(c Config) *Circuit {
+	return &Circuit{
+		forced:  make(chan states),
+		inspect: make(chan chan status),
 		allow:   make(chan bool),
 		success: make(chan time.Duration),
 		failure: make(chan time.Duration),
-		config:  c,
+		ignored: make(chan struct{}),
+		done:    make(chan struct{}),
+		config:  c.withDefaults(),
 	}
-
-	go b.run()
-
-	return b
 }
 
 // NewBreaker constructs a new circuit breaker, initially closed. The breaker
 // opens after failureRatio failures per success, and only after
 // DefaultMinObservations have been made.
-func NewBreaker(failureRatio float64) breaker {
-	return newBreaker(breakerConfig{
+func NewBreaker(failureRatio float64) *Circuit {
+	return New(Config{
 		MinObservations: DefaultMinObservations,
 		FailureRatio:    failureRatio,
 	})
 }
 
-// WithMinObservation sets the mininum observation value
-func (b *breaker) WithMinObservation(min uint) {
-	b.config.MinObservations = min
+// start runs the state machine with the current config on first use.
+func (b *Circuit) start() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.started {
+		b.started = true
+		go b.run(b.config)
+	}
+}
+
+// Close stops the state machine of the circuit so it can be garbage
+// collected.  Once closed, the circuit allows every request and ignores
+// results, its State is Closed and its Stats are empty.
+func (b *Circuit) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.started = true
+	if !b.stopped {
+		b.stopped = true
+		close(b.done)
+	}
+
+	return nil
+}
+
+// configure applies a change to the config unless the state machine has
+// already started.
+func (b *Circuit) configure(f func(*Config)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.started {
+		f(&b.config)
+		b.config = b.config.withDefaults()
+	}
+}
+
+// OnTransition registers f to be called with every change of State.  Calls
+// are made in order on a separate goroutine, so f may take its time or call
+// back into the circuit, though a slow f delays the following transitions.
+func (b *Circuit) OnTransition(f func(Transition)) {
+	b.transitions.listen(f)
+}
+
+// WithMinObservation sets the mininum observation value.  It has no effect
+// once the breaker is in use.
+//
+// Deprecated: set Config.MinObservations and use New.
+func (b *Circuit) WithMinObservation(min uint) {
+	b.configure(func(c *Config) { c.MinObservations = min })
+}
+
+// WithWindow sets the observation window.  It has no effect once the breaker
+// is in use.
+//
+// Deprecated: set Config.Window and use New.
+func (b *Circuit) WithWindow(w time.Duration) {
+	b.configure(func(c *Config) { c.Window = w })
 }
 
-// WithWindow sets the observation window
-func (b *breaker) WithWindow(w time.Duration) {
-	b.config.Window = w
+// WithCooldown sets the cooldown time.  It has no effect once the breaker is
+// in use.
+//
+// Deprecated: set Config.Cooldown and use New.
+func (b *Circuit) WithCooldown(d time.Duration) {
+	b.configure(func(c *Config) { c.Cooldown = d })
 }
 
-// WithCooldown sets the cooldown time
-func (b *breaker) WithCooldown(c time.Duration) {
-	b.config.Cooldown = c
+// newMetric returns the observations of a window with the config.
+func (c Config) newMetric() *metric {
+	m := newMetric(c.Window, c.Bucket, c.Now)
+	m.slow = c.SlowCallDuration
+	return m
 }
 
-func (b breaker) shouldOpen(m *metric) bool {
-	s := m.Summary()
-	return s.total > b.config.MinObservations && s.rate > b.config.FailureRatio
+// cooldown returns the time to stay open after tripping trips times in a
+// row.
+func (c Config) cooldown(trips uint) time.Duration {
+	if c.CooldownPolicy != nil {
+		return c.CooldownPolicy(trips)
+	}
+	return c.Cooldown
+}
+
+func (c Config) shouldOpen(m *metric) bool {
+	return c.exceeded(m.Summary())
+}
+
+// exceeded returns whether the observations exceed the thresholds that open
+// the circuit.
+func (c Config) exceeded(s Summary) bool {
+	if s.Total <= c.MinObservations {
+		return false
+	}
+	return s.Rate > c.FailureRatio || c.SlowCallDuration > 0 && s.SlowRate > c.SlowCallRatio
 }
 
 /*
@@ -128,29 +344,67 @@ sed -n 's/^dot//p' | dot -Tsvg -ostates.svg
 dot digraph {
 dot  reset -> closed    [label="stats and time reset"]
 dot  closed -> tripped  [label="failed and failure rate exceeded"]
+dot  closed -> tripped  [label="slow and slow call rate exceeded"]
 dot  closed -> closed   [label="succeed and update stats"]
 dot  closed -> closed   [label="failed and update stats"]
 dot
 dot  tripped -> open      [label="timeout scheduled"]
 dot  open -> reset        [label="succeed"]
 dot  open -> halfopen     [label="timeout expired"]
+dot  halfopen -> halfopen [label="allowed probe"]
+dot  halfopen -> halfopen [label="succeed and probe freed"]
+dot  halfopen -> halfopen [label="ignored and probe freed"]
+dot  halfopen -> reset    [label="succeeded required probes"]
 dot  halfopen -> tripped  [label="failed"]
-dot  halfopen -> tripped  [label="allowed one"]
+dot  halfopen -> tripped  [label="all probes timed out"]
 dot }
 */
-func (b breaker) run() {
+func (b *Circuit) run(c Config) {
 	var (
 		state   states
+		current State
 		timeout <-chan time.Time
+		until   time.Time // end of the cooldown when open
 		metrics *metric
+
+		probes    uint // in flight when half-open
+		successes uint // consecutive when half-open
+		trips     uint // in a row since closed
 	)
 
 	for {
 		//println(state, len(timeout), metrics)
+		if next := state.public(); next != current {
+			b.transitions.notify(Transition{
+				From:    current,
+				To:      next,
+				Time:    c.Now(),
+				Summary: metrics.Summary(),
+			})
+			current = next
+		}
+
+		// Expired timeouts take precedence over pending requests.
+		if state == open || state == halfopen {
+			select {
+			case <-timeout:
+				if state == open {
+					timeout = nil
+					probes, successes = 0, 0
+					state = halfopen
+				} else {
+					state = tripped
+				}
+				continue
+			default:
+			}
+		}
+
 		switch state {
 		case reset:
-			metrics = newMetric(b.config.Window, b.config.Now)
+			metrics = c.newMetric()
 			timeout = nil
+			trips = 0
 			state = closed
 
 		case closed:
@@ -158,16 +412,27 @@ func (b breaker) run() {
 			case b.allow <- true:
 			case d := <-b.success:
 				metrics.Success(d)
+				if c.SlowCallDuration > 0 && d >= c.SlowCallDuration && c.shouldOpen(metrics) {
+					state = tripped
+				}
 			case d := <-b.failure:
 				metrics.Failure(d)
-				if b.shouldOpen(metrics) {
+				if c.shouldOpen(metrics) {
 					state = tripped
 				}
-			case state = <-b.force:
+			case <-b.ignored:
+			case state = <-b.forced:
+			case reply := <-b.inspect:
+				reply <- status{state.public(), metrics.Summary(), 0}
+			case <-b.done:
+				return
 			}
 
 		case tripped:
-			timeout = b.config.After(b.config.Cooldown)
+			trips++
+			cooldown := c.cooldown(trips)
+			timeout = c.After(cooldown)
+			until = c.Now().Add(cooldown)
 			state = open
 
 		case open:
@@ -176,20 +441,52 @@ func (b breaker) run() {
 			case <-b.success:
 				state = reset
 			case <-b.failure:
+			case <-b.ignored:
 			case <-timeout:
+				timeout = nil
+				probes, successes = 0, 0
 				state = halfopen
-			case state = <-b.force:
+			case state = <-b.forced:
+			case reply := <-b.inspect:
+				reply <- status{state.public(), metrics.Summary(), remaining(until, c.Now())}
+			case <-b.done:
+				return
 			}
 
 		case halfopen:
+			permit := probes < c.HalfOpenProbes
 			select {
-			case b.allow <- true:
-				state = tripped
+			case b.allow <- permit:
+				if permit {
+					probes++
+					if probes == c.HalfOpenProbes {
+						// Trip again unless a probe reports within the cooldown.
+						timeout = c.After(c.Cooldown)
+					}
+				}
 			case <-b.success:
-				state = reset
+				successes++
+				if successes >= c.HalfOpenSuccesses {
+					state = reset
+				} else if probes > 0 {
+					probes--
+					timeout = nil
+				}
 			case <-b.failure:
 				state = tripped
-			case state = <-b.force:
+			case <-b.ignored:
+				// A probe given up by its client frees its place.
+				if probes > 0 {
+					probes--
+					timeout = nil
+				}
+			case <-timeout:
+				state = tripped
+			case state = <-b.forced:
+			case reply := <-b.inspect:
+				reply <- status{state.public(), metrics.Summary(), 0}
+			case <-b.done:
+				return
 			}
 		}
 	}
@@ -198,28 +495,98 @@ func (b breaker) run() {
 // Success informs the circuit that a request to the underlying resource has
 // completed successfully. Every Allowed request should signal either Success
 // or Failure.
-func (b breaker) Success(d time.Duration) {
-	b.success <- d
+func (b *Circuit) Success(d time.Duration) {
+	b.start()
+	select {
+	case b.success <- d:
+	case <-b.done:
+	}
 }
 
 // Failure informs the circuit that a request to the underlying resource has
 // failed. Every Allowed request should signal either Success or Failure.
-func (b breaker) Failure(d time.Duration) {
-	b.failure <- d
+func (b *Circuit) Failure(d time.Duration) {
+	b.start()
+	select {
+	case b.failure <- d:
+	case <-b.done:
+	}
+}
+
+// Ignore informs the circuit that an allowed request ended without counting
+// as Success or Failure, freeing its place when it probed.
+func (b *Circuit) Ignore() {
+	b.start()
+	select {
+	case b.ignored <- struct{}{}:
+	case <-b.done:
+	}
 }
 
 // Allow returns true if a new request should be allowed to proceed to the
 // underlying resource.
-func (b breaker) Allow() bool {
-	return <-b.allow
+func (b *Circuit) Allow() bool {
+	b.start()
+	select {
+	case allow := <-b.allow:
+		return allow
+	case <-b.done:
+		return true
+	}
 }
 
-// Trip manually opens the circuit.
-func (b breaker) trip() {
-	b.force <- tripped
+// Trip manually opens the circuit.  It stays open for the cooldown before
+// probing with up to HalfOpenProbes requests like a circuit opened by
+// failures.
+func (b *Circuit) Trip() {
+	b.force(tripped)
+}
+
+// Reset manually closes the circuit and clears its observations.
+func (b *Circuit) Reset() {
+	b.force(reset)
+}
+
+func (b *Circuit) force(s states) {
+	b.start()
+	select {
+	case b.forced <- s:
+	case <-b.done:
+	}
+}
+
+func (b *Circuit) status() status {
+	b.start()
+	reply := make(chan status, 1)
+	select {
+	case b.inspect <- reply:
+		return <-reply
+	case <-b.done:
+		return status{state: Closed}
+	}
+}
+
+// State returns whether the circuit is currently closed, open or half-open.
+func (b *Circuit) State() State {
+	return b.status().state
+}
+
+// RetryAfter returns the remaining cooldown of an open circuit, after which
+// it allows requests to probe.  It returns 0 unless the circuit is open.
+func (b *Circuit) RetryAfter() time.Duration {
+	return b.status().retryAfter
+}
+
+// remaining returns the time from now until the deadline, at least 0.
+func remaining(until, now time.Time) time.Duration {
+	if d := until.Sub(now); d > 0 {
+		return d
+	}
+	return 0
 }
 
-// Reset manually closes the circuit.
-func (b breaker) reset() {
-	b.force <- reset
+// Stats returns the observations made over the current window.  The window
+// is cleared when the circuit closes after being open.
+func (b *Circuit) Stats() Summary {
+	return b.status().summary
 }
@@ -16,7 +16,7 @@ func TestNewBreakerAllows(t *testing.T) {
 func TestBreakerSuccessClosesOpenBreaker(t *testing.T) {
 	b := NewBreaker(0)
 
-	b.trip()
+	b.Trip()
 
 	if b.Allow() {
 		t.Fatal("expected new breaker to be open after being tripped")
@@ -70,12 +70,12 @@ func TestBreakerFailDoesNotTripBreakerAt1PrecentThreshold(t *testing.T) {
 func TestBreakerAllowsASingleRequestAfterNapTime(t *testing.T) {
 	after := make(chan time.Time)
 
-	c := newBreaker(breakerConfig{
+	c := New(Config{
 		Window: 5 * time.Second,
 		After:  func(time.Duration) <-chan time.Time { return after },
 	})
 
-	c.trip()
+	c.Trip()
 
 	after <- time.Now()
 
@@ -91,12 +91,12 @@ func TestBreakerAllowsASingleRequestAfterNapTime(t *testing.T) {
 func TestBreakerClosesAfterSuccessAfterNapTime(t *testing.T) {
 	after := make(chan time.Time)
 
-	b := newBreaker(breakerConfig{
+	b := New(Config{
 		Window: 5 * time.Second,
 		After:  func(time.Duration) <-chan time.Time { return after },
 	})
 
-	b.trip()
+	b.Trip()
 
 	after <- time.Now()
 
@@ -118,7 +118,7 @@ func TestBreakerClosesAfterSuccessAfterNapTime(t *testing.T) {
 func TestBreakerReschedulesOnFailureInHalfOpen(t *testing.T) {
 	afters := make(chan chan time.Time)
 
-	b := newBreaker(breakerConfig{
+	b := New(Config{
 		Window: 5 * time.Second,
 		After: func(time.Duration) <-chan time.Time {
 			after := make(chan time.Time)
@@ -127,7 +127,7 @@ func TestBreakerReschedulesOnFailureInHalfOpen(t *testing.T) {
 		},
 	})
 
-	b.trip()
+	b.Trip()
 
 	(<-afters) <- time.Now()
 
@@ -157,3 +157,286 @@ func TestNewBreakerOptions(t *testing.T) {
 		t.Fatal("unexpected configuration values")
 	}
 }
+
+func TestNewBreakerOptionsApplyBeforeUse(t *testing.T) {
+	cooldowns := make(chan time.Duration, 1)
+
+	b := New(Config{
+		After: func(d time.Duration) <-chan time.Time {
+			cooldowns <- d
+			return make(chan time.Time)
+		},
+	})
+	b.WithCooldown(3 * time.Second)
+
+	b.Trip()
+
+	if got, want := <-cooldowns, 3*time.Second; got != want {
+		t.Fatalf("expected cooldown of %s, got %s", want, got)
+	}
+
+	b.WithCooldown(time.Second)
+
+	if got, want := b.config.Cooldown, 3*time.Second; got != want {
+		t.Fatalf("expected cooldown to be unchanged once in use, got %s", got)
+	}
+}
+
+func TestNewMinObservations(t *testing.T) {
+	b := New(Config{MinObservations: 3})
+
+	for i := 0; i < 3; i++ {
+		b.Failure(0)
+	}
+
+	if !b.Allow() {
+		t.Fatal("expected to stay closed until more than the minimum observations")
+	}
+
+	b.Failure(0)
+
+	if b.Allow() {
+		t.Fatal("expected to open after the minimum observations")
+	}
+}
+
+func TestBreakerState(t *testing.T) {
+	after := make(chan time.Time)
+
+	b := New(Config{
+		After: func(time.Duration) <-chan time.Time { return after },
+	})
+
+	if got, want := b.State(), Closed; got != want {
+		t.Fatalf("expected new breaker to be %s, got %s", want, got)
+	}
+
+	b.Trip()
+
+	if got, want := b.State(), Open; got != want {
+		t.Fatalf("expected tripped breaker to be %s, got %s", want, got)
+	}
+
+	after <- time.Now()
+
+	if got, want := b.State(), HalfOpen; got != want {
+		t.Fatalf("expected breaker to be %s after cooldown, got %s", want, got)
+	}
+
+	b.Reset()
+
+	if got, want := b.State(), Closed; got != want {
+		t.Fatalf("expected reset breaker to be %s, got %s", want, got)
+	}
+}
+
+func TestBreakerStats(t *testing.T) {
+	b := New(Config{FailureRatio: 0.5, MinObservations: 10})
+
+	b.Success(0)
+	b.Success(0)
+	b.Success(0)
+	b.Failure(0)
+
+	if got, want := b.Stats(), (Summary{Total: 4, Errors: 1, Rate: 0.25}); got != want {
+		t.Fatalf("expected stats %+v, got %+v", want, got)
+	}
+
+	b.Trip()
+	b.Reset()
+
+	if got, want := b.Stats(), (Summary{}); got != want {
+		t.Fatalf("expected stats to be cleared after reset, got %+v", got)
+	}
+}
+
+func TestBreakerTripsOnSlowCalls(t *testing.T) {
+	b := New(Config{
+		FailureRatio:     1,
+		MinObservations:  4,
+		SlowCallDuration: 10 * time.Second,
+		SlowCallRatio:    0.5,
+	})
+
+	for i := 0; i < 3; i++ {
+		b.Success(time.Second)
+		b.Success(20 * time.Second)
+	}
+
+	if !b.Allow() {
+		t.Fatal("expected to stay closed at the slow call ratio")
+	}
+
+	b.Success(20 * time.Second)
+
+	if b.Allow() {
+		t.Fatal("expected to open over the slow call ratio")
+	}
+}
+
+func TestBreakerIgnoresSlowCallsWhenDisabled(t *testing.T) {
+	b := New(Config{FailureRatio: 1})
+
+	for i := 0; i < 100; i++ {
+		b.Success(time.Hour)
+	}
+
+	if !b.Allow() {
+		t.Fatal("expected slow calls to be ignored without a slow call duration")
+	}
+}
+
+func TestBreakerAllowsConfiguredProbesInHalfOpen(t *testing.T) {
+	after := make(chan time.Time)
+
+	b := New(Config{
+		HalfOpenProbes:    2,
+		HalfOpenSuccesses: 3,
+		After:             func(time.Duration) <-chan time.Time { return after },
+	})
+
+	b.Trip()
+	after <- time.Now()
+
+	if !b.Allow() || !b.Allow() {
+		t.Fatal("expected to allow two probes in half-open")
+	}
+
+	if b.Allow() {
+		t.Fatal("expected to allow no more than two probes in flight")
+	}
+
+	b.Success(0)
+
+	if !b.Allow() {
+		t.Fatal("expected a successful probe to free its slot")
+	}
+
+	b.Success(0)
+
+	if got, want := b.State(), HalfOpen; got != want {
+		t.Fatalf("expected to stay %s until the required successes, got %s", want, got)
+	}
+
+	b.Success(0)
+
+	if got, want := b.State(), Closed; got != want {
+		t.Fatalf("expected to be %s after the required successes, got %s", want, got)
+	}
+}
+
+func TestBreakerReopensOnProbeFailure(t *testing.T) {
+	after := make(chan time.Time)
+
+	b := New(Config{
+		HalfOpenProbes:    2,
+		HalfOpenSuccesses: 2,
+		After:             func(time.Duration) <-chan time.Time { return after },
+	})
+
+	b.Trip()
+	after <- time.Now()
+
+	b.Allow()
+	b.Allow()
+	b.Success(0)
+	b.Failure(0)
+
+	if got, want := b.State(), Open; got != want {
+		t.Fatalf("expected a failed probe to be %s, got %s", want, got)
+	}
+}
+
+func TestBreakerReopensWhenProbesDoNotReport(t *testing.T) {
+	after := make(chan time.Time)
+
+	b := New(Config{
+		After: func(time.Duration) <-chan time.Time { return after },
+	})
+
+	b.Trip()
+	after <- time.Now()
+
+	if !b.Allow() {
+		t.Fatal("expected to allow a probe")
+	}
+
+	after <- time.Now()
+
+	if got, want := b.State(), Open; got != want {
+		t.Fatalf("expected to be %s when the probe did not report, got %s", want, got)
+	}
+}
+
+func TestBreakerRetryAfter(t *testing.T) {
+	now := time.Now()
+	after := make(chan time.Time)
+
+	b := New(Config{
+		Cooldown: 10 * time.Second,
+		Now:      func() time.Time { return now },
+		After:    func(time.Duration) <-chan time.Time { return after },
+	})
+
+	if got := b.RetryAfter(); got != 0 {
+		t.Fatalf("expected no retry after when closed, got %s", got)
+	}
+
+	b.Trip()
+	b.State() // wait for the cooldown to be scheduled
+	now = now.Add(4 * time.Second)
+
+	if got, want := b.RetryAfter(), 6*time.Second; got != want {
+		t.Fatalf("expected retry after %s, got %s", want, got)
+	}
+}
+
+func TestConfigBucketNotWiderThanWindow(t *testing.T) {
+	c := Config{Window: 500 * time.Millisecond}.withDefaults()
+
+	if got, want := c.Bucket, 500*time.Millisecond; got != want {
+		t.Fatalf("expected bucket to be narrowed to the window of %s, got %s", want, got)
+	}
+
+	b := NewSync(Config{Window: 2 * time.Second, Bucket: 100 * time.Millisecond})
+	b.Failure(0)
+
+	if b.Allow() {
+		t.Fatal("expected a circuit with sub-second buckets to trip")
+	}
+}
+
+func TestBreakerExpiredTimeoutsTakePrecedence(t *testing.T) {
+	// Timeouts that already fired when the circuit schedules them, as with
+	// a fake clock, are applied before answering requests.  Only the
+	// cooldown and the probe timeout expire, the second cooldown does not.
+	for i := 0; i < 100; i++ {
+		timeouts := 0
+		b := New(Config{After: func(time.Duration) <-chan time.Time {
+			c := make(chan time.Time, 1)
+			if timeouts++; timeouts <= 2 {
+				c <- time.Now()
+			}
+			return c
+		}})
+
+		b.Trip()
+
+		if want, got := HalfOpen, b.State(); want != got {
+			b.Close()
+			t.Fatalf("%d: expected an expired cooldown to apply first, want %s, got %s", i, want, got)
+		}
+
+		if !b.Allow() {
+			b.Close()
+			t.Fatalf("%d: expected to allow a probe", i)
+		}
+
+		if want, got := Open, b.State(); want != got {
+			b.Close()
+			t.Fatalf("%d: expected an expired probe timeout to apply first, want %s, got %s", i, want, got)
+		}
+
+		b.Close()
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Package breakertest provides utilities for testing the tuning of breakers and
+custom Breaker implementations: a Clock controlled by the test, a Simulate
+function driving scripted Traffic through a breaker, and a Recorder to assert
+on the transitions of a circuit.
+*/
+package breakertest
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/handy/breaker"
+)
+
+// Clock is a clock that only moves when advanced, to be used as the Now and
+// After of a breaker.Config.  It is safe for concurrent use.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []timer
+}
+
+type timer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewClock constructs a clock set to start.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// After returns a channel receiving the time once the clock was advanced by
+// d, like time.After.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := timer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+	} else {
+		c.timers = append(c.timers, t)
+	}
+	return t.c
+}
+
+// Advance moves the clock forward by d, firing the channels of After that
+// expire in order.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].at.Before(c.timers[j].at)
+	})
+
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+		} else {
+			t.c <- t.at
+		}
+	}
+	c.timers = pending
+}
+
+// Config sets the Now and After of config to the clock.
+func (c *Clock) Config(config breaker.Config) breaker.Config {
+	config.Now = c.Now
+	config.After = c.After
+	return config
+}
+
+// Traffic is a phase of requests made by Simulate.
+type Traffic struct {
+	// Rate is the number of requests per second.
+	Rate int
+
+	// Duration is the length of the phase.
+	Duration time.Duration
+
+	// FailureRatio is the share of allowed requests that fail, between 0.0
+	// and 1.0.  Failures are spread evenly over the phase.
+	FailureRatio float64
+
+	// Latency returns the duration reported for each request, like
+	// UniformLatency.  Requests take no time when nil.
+	Latency func() time.Duration
+}
+
+// ConstantLatency reports every request to take d.
+func ConstantLatency(d time.Duration) func() time.Duration {
+	return func() time.Duration {
+		return d
+	}
+}
+
+// UniformLatency reports requests to take a random duration between min and
+// max.
+func UniformLatency(min, max time.Duration) func() time.Duration {
+	return func() time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(rand.Int63n(int64(max-min)))
+	}
+}
+
+// Result counts the requests made by Simulate.
+type Result struct {
+	Requests  int // made
+	Allowed   int // by the breaker
+	Rejected  int // by the breaker
+	Succeeded int // of the allowed
+	Failed    int // of the allowed
+}
+
+// Simulate drives the traffic through b in order, advancing clock between
+// requests so they are evenly spaced at the rate of each phase.  Allowed
+// requests report Success or Failure to b with their latency.
+func Simulate(b breaker.Breaker, clock *Clock, traffic ...Traffic) Result {
+	var res Result
+
+	for _, t := range traffic {
+		if t.Rate <= 0 {
+			clock.Advance(t.Duration)
+			continue
+		}
+
+		interval := time.Second / time.Duration(t.Rate)
+		count := int(t.Duration / interval)
+		allowed, failures := 0, 0
+
+		for i := 0; i < count; i++ {
+			clock.Advance(interval)
+			res.Requests++
+
+			if !b.Allow() {
+				res.Rejected++
+				continue
+			}
+			res.Allowed++
+			allowed++
+
+			var d time.Duration
+			if t.Latency != nil {
+				d = t.Latency()
+			}
+
+			// fail whenever the failures fall behind the ratio of the allowed
+			if int(t.FailureRatio*float64(allowed)) > failures {
+				failures++
+				res.Failed++
+				b.Failure(d)
+			} else {
+				res.Succeeded++
+				b.Success(d)
+			}
+		}
+	}
+
+	return res
+}
+
+// DefaultWait is the time a Recorder waits for transitions to be delivered.
+const DefaultWait = time.Second
+
+// Recorder records the transitions of a circuit, which are delivered
+// asynchronously.  It is safe for concurrent use.
+type Recorder struct {
+	mu          sync.Mutex
+	transitions []breaker.Transition
+	changed     chan struct{}
+}
+
+// Record constructs a Recorder listening to the transitions of b, like a
+// breaker.Circuit or breaker.SyncCircuit.
+func Record(b interface {
+	OnTransition(func(breaker.Transition))
+}) *Recorder {
+	r := &Recorder{changed: make(chan struct{})}
+	b.OnTransition(r.record)
+	return r
+}
+
+func (r *Recorder) record(t breaker.Transition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.transitions = append(r.transitions, t)
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// Transitions returns the transitions delivered so far.
+func (r *Recorder) Transitions() []breaker.Transition {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]breaker.Transition(nil), r.transitions...)
+}
+
+// Wait returns the transitions once at least n were delivered, or those
+// delivered before the timeout.
+func (r *Recorder) Wait(n int, timeout time.Duration) []breaker.Transition {
+	deadline := time.After(timeout)
+	for {
+		r.mu.Lock()
+		got, changed := append([]breaker.Transition(nil), r.transitions...), r.changed
+		r.mu.Unlock()
+
+		if len(got) >= n {
+			return got
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return got
+		}
+	}
+}
+
+// Expect fails the test unless the circuit transitioned to the states in
+// order, waiting up to DefaultWait for them to be delivered.
+func (r *Recorder) Expect(t testing.TB, states ...breaker.State) {
+	t.Helper()
+
+	got := r.Wait(len(states), DefaultWait)
+	if len(got) != len(states) {
+		t.Fatalf("expected transitions to %v, got %v", states, to(got))
+	}
+
+	for i, want := range states {
+		if got[i].To != want {
+			t.Fatalf("expected transitions to %v, got %v", states, to(got))
+		}
+	}
+}
+
+func to(transitions []breaker.Transition) []breaker.State {
+	states := make([]breaker.State, len(transitions))
+	for i, t := range transitions {
+		states[i] = t.To
+	}
+	return states
+}
+
+// ExpectState fails the test unless b, like a breaker.Circuit, is in the
+// state.
+func ExpectState(t testing.TB, b interface{ State() breaker.State }, want breaker.State) {
+	t.Helper()
+
+	if got := b.State(); got != want {
+		t.Fatalf("expected state %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,105 @@
+package breakertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/streadway/handy/breaker"
+)
+
+func TestClockFiresTimersInOrder(t *testing.T) {
+	start := time.Now()
+	c := NewClock(start)
+
+	late := c.After(2 * time.Second)
+	early := c.After(time.Second)
+
+	c.Advance(time.Second)
+
+	select {
+	case got := <-early:
+		if want := start.Add(time.Second); !got.Equal(want) {
+			t.Fatalf("want %s, got %s", want, got)
+		}
+	default:
+		t.Fatal("expected the early timer to fire")
+	}
+
+	select {
+	case <-late:
+		t.Fatal("expected the late timer not to fire yet")
+	default:
+	}
+
+	c.Advance(time.Second)
+
+	select {
+	case <-late:
+	default:
+		t.Fatal("expected the late timer to fire")
+	}
+
+	if want, got := start.Add(2*time.Second), c.Now(); !got.Equal(want) {
+		t.Fatalf("want %s, got %s", want, got)
+	}
+}
+
+func TestSimulateCountsRequests(t *testing.T) {
+	c := NewClock(time.Now())
+	b := breaker.NewSync(c.Config(breaker.Config{FailureRatio: 1}))
+
+	res := Simulate(b, c, Traffic{
+		Rate:         100,
+		Duration:     2 * time.Second,
+		FailureRatio: 0.25,
+		Latency:      ConstantLatency(time.Millisecond),
+	})
+
+	want := Result{Requests: 200, Allowed: 200, Succeeded: 150, Failed: 50}
+	if res != want {
+		t.Fatalf("want %+v, got %+v", want, res)
+	}
+}
+
+func TestSimulatePartialFailures(t *testing.T) {
+	const rate = 100
+	const window = 5 * time.Second
+
+	c := NewClock(time.Now())
+	b := breaker.New(c.Config(breaker.Config{
+		Window:          window,
+		MinObservations: rate / 5,
+		FailureRatio:    0.05,
+		Cooldown:        2 * window,
+	}))
+	defer b.Close()
+
+	rec := Record(b)
+
+	res := Simulate(b, c, Traffic{Rate: rate, Duration: window, FailureRatio: 0.20})
+	if res.Rejected == 0 {
+		t.Fatal("expected to trip at a high failure rate")
+	}
+	ExpectState(t, b, breaker.Open)
+
+	c.Advance(2 * window)
+	ExpectState(t, b, breaker.HalfOpen)
+
+	Simulate(b, c, Traffic{Rate: rate, Duration: window, FailureRatio: 0.02})
+	ExpectState(t, b, breaker.Closed)
+
+	Simulate(b, c, Traffic{Rate: rate, Duration: window, FailureRatio: 0.06})
+	ExpectState(t, b, breaker.Open)
+
+	rec.Expect(t, breaker.Open, breaker.HalfOpen, breaker.Closed, breaker.Open)
+}
+
+func TestUniformLatency(t *testing.T) {
+	latency := UniformLatency(time.Millisecond, 2*time.Millisecond)
+
+	for i := 0; i < 100; i++ {
+		if d := latency(); d < time.Millisecond || d >= 2*time.Millisecond {
+			t.Fatalf("expected latency within bounds, got %s", d)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultMaxConcurrent is the default number of calls a Bulkhead allows in
+// flight.
+const DefaultMaxConcurrent = 10
+
+// ErrBulkheadFull is returned by the transport when a Bulkhead rejects a
+// request because too many are in flight.
+var ErrBulkheadFull = errors.New("bulkhead full")
+
+// BulkheadConfig parameterizes a Bulkhead.
+type BulkheadConfig struct {
+	// MaxConcurrent is the number of calls allowed in flight.  Defaults to
+	// DefaultMaxConcurrent.
+	MaxConcurrent uint
+
+	// MaxQueue is the number of calls waiting for a call in flight to
+	// complete when MaxConcurrent are.  Further calls are rejected.  Zero
+	// rejects calls without waiting.
+	MaxQueue uint
+
+	// QueueTimeout is how long a queued call waits before it is rejected.
+	// Zero waits until a call completes or the context of the call is done.
+	QueueTimeout time.Duration
+
+	// Classify determines whether a call reported with an error failed,
+	// defaulting to DefaultErrorClassifier.
+	Classify ErrorClassifier
+}
+
+// BulkheadStats is the count of calls of a Bulkhead.
+type BulkheadStats struct {
+	InFlight  uint // allowed calls not yet reported
+	Queued    uint // calls waiting for a call in flight to complete
+	Allowed   uint // calls allowed since construction
+	Rejected  uint // calls rejected for the limit since construction
+	Succeeded uint // calls reported as Succeeded
+	Failed    uint // calls reported as Failed
+}
+
+// Bulkhead is a Breaker that limits the calls in flight to a dependency
+// rather than the rate of errors, so a slow dependency can't take up every
+// connection or goroutine.  Allowed calls must report their result with
+// Success, Failure, Ignore or Report to free their place.  Rejections are counted
+// apart from failures, and reported by the transport as ErrBulkheadFull.
+//
+// Like any Breaker, it composes with Handler and Transport.  It is safe for
+// concurrent use.
+type Bulkhead struct {
+	config BulkheadConfig
+	slots  chan struct{}
+	queue  chan struct{}
+
+	mu    sync.Mutex
+	stats BulkheadStats
+}
+
+// NewBulkhead constructs a bulkhead from the config.
+func NewBulkhead(c BulkheadConfig) *Bulkhead {
+	if c.MaxConcurrent == 0 {
+		c.MaxConcurrent = DefaultMaxConcurrent
+	}
+
+	if c.Classify == nil {
+		c.Classify = DefaultErrorClassifier
+	}
+
+	return &Bulkhead{
+		config: c,
+		slots:  make(chan struct{}, c.MaxConcurrent),
+		queue:  make(chan struct{}, c.MaxQueue),
+	}
+}
+
+func (b *Bulkhead) count(f func(*BulkheadStats)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	f(&b.stats)
+}
+
+func (b *Bulkhead) allowed(s *BulkheadStats) {
+	s.Allowed++
+	s.InFlight++
+}
+
+func (b *Bulkhead) rejected(s *BulkheadStats) {
+	s.Rejected++
+}
+
+// Allow returns true when a call may proceed, waiting in the queue while the
+// limit is reached.
+func (b *Bulkhead) Allow() bool {
+	return b.AllowContext(context.Background())
+}
+
+// AllowContext is like Allow, but stops waiting in the queue and returns
+// false when ctx is done.  Calls given up by their context don't count as
+// rejected.
+func (b *Bulkhead) AllowContext(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		b.count(b.allowed)
+		return true
+	default:
+	}
+
+	select {
+	case b.queue <- struct{}{}:
+	default:
+		b.count(b.rejected)
+		return false
+	}
+	b.count(func(s *BulkheadStats) { s.Queued++ })
+	defer func() {
+		<-b.queue
+		b.count(func(s *BulkheadStats) { s.Queued-- })
+	}()
+
+	var timeout <-chan time.Time
+	if b.config.QueueTimeout > 0 {
+		t := time.NewTimer(b.config.QueueTimeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		b.count(b.allowed)
+		return true
+	case <-timeout:
+		b.count(b.rejected)
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release frees the place of a completed call.
+func (b *Bulkhead) release(f func(*BulkheadStats)) {
+	select {
+	case <-b.slots:
+		b.count(func(s *BulkheadStats) {
+			s.InFlight--
+			f(s)
+		})
+	default:
+		// unbalanced report without an allowed call
+	}
+}
+
+// Success reports an allowed call as completed successfully.
+func (b *Bulkhead) Success(time.Duration) {
+	b.release(func(s *BulkheadStats) { s.Succeeded++ })
+}
+
+// Failure reports an allowed call as failed.
+func (b *Bulkhead) Failure(time.Duration) {
+	b.release(func(s *BulkheadStats) { s.Failed++ })
+}
+
+// Ignore frees the place of an allowed call without counting it as either
+// succeeded or failed.
+func (b *Bulkhead) Ignore() {
+	b.release(func(*BulkheadStats) {})
+}
+
+// Report reports an allowed call by the classification of err.  Ignored
+// calls free their place without counting as either.
+func (b *Bulkhead) Report(d time.Duration, err error) {
+	report(b, b.config.Classify, d, err)
+}
+
+// Stats returns the counts of calls of the bulkhead.
+func (b *Bulkhead) Stats() BulkheadStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.stats
+}
+
+// rejection returns the error of the transport for rejected calls.
+func (b *Bulkhead) rejection() error {
+	return ErrBulkheadFull
+}
@@ -0,0 +1,123 @@
+package breaker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBulkheadLimitsInFlight(t *testing.T) {
+	b := NewBulkhead(BulkheadConfig{MaxConcurrent: 2})
+
+	if !b.Allow() || !b.Allow() {
+		t.Fatal("expected to allow up to the limit")
+	}
+
+	if b.Allow() {
+		t.Fatal("expected to reject over the limit without a queue")
+	}
+
+	b.Failure(0)
+
+	if !b.Allow() {
+		t.Fatal("expected a completed call to free its place")
+	}
+
+	b.Success(0)
+	b.Report(0, context.Canceled)
+
+	want := BulkheadStats{Allowed: 3, Rejected: 1, Succeeded: 1, Failed: 1}
+	if got := b.Stats(); want != got {
+		t.Fatalf("want %+v, got %+v", want, got)
+	}
+}
+
+func TestBulkheadWithContextFreesCanceledCalls(t *testing.T) {
+	b := WithContext(NewBulkhead(BulkheadConfig{MaxConcurrent: 1}), DefaultErrorClassifier)
+
+	if !b.AllowContext(context.Background()) {
+		t.Fatal("expected to allow up to the limit")
+	}
+
+	b.Report(0, context.Canceled)
+
+	if !b.AllowContext(context.Background()) {
+		t.Fatal("expected a canceled call to free its place")
+	}
+}
+
+func TestBulkheadQueueWaitsForPlace(t *testing.T) {
+	b := NewBulkhead(BulkheadConfig{MaxConcurrent: 1, MaxQueue: 1})
+
+	b.Allow()
+
+	allowed := make(chan bool)
+	go func() { allowed <- b.Allow() }()
+
+	for b.Stats().Queued != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if b.Allow() {
+		t.Fatal("expected to reject when the queue is full")
+	}
+
+	b.Success(0)
+
+	if !<-allowed {
+		t.Fatal("expected the queued call to be allowed once a place freed")
+	}
+
+	if want, got := (BulkheadStats{InFlight: 1, Allowed: 2, Rejected: 1, Succeeded: 1}), b.Stats(); want != got {
+		t.Fatalf("want %+v, got %+v", want, got)
+	}
+}
+
+func TestBulkheadQueueTimeout(t *testing.T) {
+	b := NewBulkhead(BulkheadConfig{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: time.Millisecond})
+
+	b.Allow()
+
+	if b.Allow() {
+		t.Fatal("expected to reject once the queue timeout expires")
+	}
+
+	if want, got := uint(1), b.Stats().Rejected; want != got {
+		t.Fatalf("want %d rejected, got %d", want, got)
+	}
+}
+
+func TestBulkheadQueueStopsWithContext(t *testing.T) {
+	b := NewBulkhead(BulkheadConfig{MaxConcurrent: 1, MaxQueue: 1})
+
+	b.Allow()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	if b.AllowContext(ctx) {
+		t.Fatal("expected to stop waiting when the context is done")
+	}
+
+	if want, got := (BulkheadStats{InFlight: 1, Allowed: 1}), b.Stats(); want != got {
+		t.Fatalf("expected the canceled call not to count as rejected, got %+v", got)
+	}
+}
+
+func TestBulkheadTransportRejects(t *testing.T) {
+	b := NewBulkhead(BulkheadConfig{MaxConcurrent: 1})
+	b.Allow()
+
+	next := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		t.Fatal("expected the rejected request not to be forwarded")
+		return nil, nil
+	})
+
+	_, err := Transport(b, DefaultResponseValidator, next).RoundTrip(httptest.NewRequest("GET", "http://example.com/", nil))
+
+	if err != ErrBulkheadFull {
+		t.Fatalf("want %v, got %v", ErrBulkheadFull, err)
+	}
+}
@@ -0,0 +1,175 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultConsecutiveFailures is the default number of consecutive failures
+// that open a Consecutive breaker.
+const DefaultConsecutiveFailures = 5
+
+// ConsecutiveConfig parameterizes a Consecutive breaker.
+type ConsecutiveConfig struct {
+	// Failures is the number of consecutive failures that open the circuit.
+	// Defaults to DefaultConsecutiveFailures.
+	Failures uint
+
+	// Cooldown is the time to wait before trying once when open.  Defaults
+	// to DefaultCooldown.
+	Cooldown time.Duration
+
+	// CooldownPolicy overrides the time to wait when open depending on how
+	// many times the circuit tripped in a row, like ExponentialCooldown.
+	// Defaults to the constant Cooldown.
+	CooldownPolicy CooldownPolicy
+
+	// Now is the clock of the circuit, defaulting to time.Now.
+	Now func() time.Time
+}
+
+// Consecutive is a Breaker that opens after a number of consecutive
+// failures regardless of how many observations were made, which suits
+// dependencies with too little traffic to reach the minimum observations of
+// a Circuit.  Like a Circuit, it allows a single probe after the cooldown and
+// closes on its success.  It is safe for concurrent use.
+type Consecutive struct {
+	config ConsecutiveConfig
+
+	mu       sync.Mutex
+	state    State
+	failures uint
+	trips    uint      // in a row since closed
+	until    time.Time // end of the cooldown when open, or of the probe when half-open
+}
+
+// NewConsecutive constructs a new consecutive failures breaker, initially
+// closed.
+func NewConsecutive(c ConsecutiveConfig) *Consecutive {
+	if c.Failures == 0 {
+		c.Failures = DefaultConsecutiveFailures
+	}
+
+	if c.Cooldown == 0 {
+		c.Cooldown = DefaultCooldown
+	}
+
+	if c.CooldownPolicy == nil {
+		c.CooldownPolicy = ConstantCooldown(c.Cooldown)
+	}
+
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+
+	return &Consecutive{config: c}
+}
+
+// Allow returns true if a new request should be allowed to proceed to the
+// underlying resource.  Once the cooldown expires a single probe is allowed,
+// another one only when the probe has not reported back within a cooldown.
+func (b *Consecutive) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Closed:
+		return true
+	default:
+		now := b.config.Now()
+		if now.Before(b.until) {
+			return false
+		}
+		b.state = HalfOpen
+		b.until = now.Add(b.config.Cooldown)
+		return true
+	}
+}
+
+// Success informs the circuit that a request to the underlying resource has
+// completed successfully, which closes the circuit.
+func (b *Consecutive) Success(time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.close()
+}
+
+// Failure informs the circuit that a request to the underlying resource has
+// failed.  Failing the probe opens the circuit again.
+func (b *Consecutive) Failure(time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Closed:
+		b.failures++
+		if b.failures >= b.config.Failures {
+			b.open()
+		}
+	case HalfOpen:
+		b.open()
+	}
+}
+
+// Ignore informs the circuit that an allowed request ended without counting
+// as Success or Failure, allowing another probe when it probed.
+func (b *Consecutive) Ignore() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == HalfOpen {
+		b.until = b.config.Now()
+	}
+}
+
+func (b *Consecutive) open() {
+	b.state = Open
+	b.trips++
+	b.until = b.config.Now().Add(b.config.CooldownPolicy(b.trips))
+}
+
+func (b *Consecutive) close() {
+	b.state = Closed
+	b.failures = 0
+	b.trips = 0
+}
+
+// Trip manually opens the circuit.
+func (b *Consecutive) Trip() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.open()
+}
+
+// Reset manually closes the circuit.
+func (b *Consecutive) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.close()
+}
+
+// State returns whether the circuit is currently closed, open or half-open.
+func (b *Consecutive) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open && !b.config.Now().Before(b.until) {
+		return HalfOpen
+	}
+	return b.state
+}
+
+// RetryAfter returns the remaining cooldown of an open circuit, after which
+// it allows a request to probe.  It returns 0 unless the circuit is open.
+func (b *Consecutive) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != Open {
+		return 0
+	}
+	return remaining(b.until, b.config.Now())
+}
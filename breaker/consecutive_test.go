@@ -0,0 +1,114 @@
+package breaker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConsecutiveOpensAfterConsecutiveFailures(t *testing.T) {
+	b := NewConsecutive(ConsecutiveConfig{Failures: 3})
+
+	b.Failure(0)
+	b.Failure(0)
+	b.Success(0)
+	b.Failure(0)
+	b.Failure(0)
+
+	if !b.Allow() {
+		t.Fatal("expected a success to reset the consecutive failures")
+	}
+
+	b.Failure(0)
+
+	if b.Allow() {
+		t.Fatal("expected to open after consecutive failures")
+	}
+
+	if got, want := b.State(), Open; got != want {
+		t.Fatalf("expected state %s, got %s", want, got)
+	}
+}
+
+func TestConsecutiveProbesAfterCooldown(t *testing.T) {
+	now := time.Now()
+	b := NewConsecutive(ConsecutiveConfig{
+		Failures: 1,
+		Cooldown: time.Second,
+		Now:      func() time.Time { return now },
+	})
+
+	b.Failure(0)
+
+	if b.Allow() {
+		t.Fatal("expected to be open during the cooldown")
+	}
+
+	now = now.Add(time.Second)
+
+	if got, want := b.State(), HalfOpen; got != want {
+		t.Fatalf("expected state %s after cooldown, got %s", want, got)
+	}
+
+	if !b.Allow() {
+		t.Fatal("expected to allow a probe after the cooldown")
+	}
+
+	if b.Allow() {
+		t.Fatal("expected to allow only a single probe")
+	}
+
+	b.Failure(0)
+	now = now.Add(time.Second / 2)
+
+	if b.Allow() {
+		t.Fatal("expected a failed probe to open for another cooldown")
+	}
+
+	now = now.Add(time.Second / 2)
+
+	if !b.Allow() {
+		t.Fatal("expected to probe again after the cooldown")
+	}
+
+	b.Success(0)
+
+	if got, want := b.State(), Closed; got != want {
+		t.Fatalf("expected state %s after a successful probe, got %s", want, got)
+	}
+}
+
+func TestConsecutiveReprobesLostProbe(t *testing.T) {
+	now := time.Now()
+	b := NewConsecutive(ConsecutiveConfig{
+		Cooldown: time.Second,
+		Now:      func() time.Time { return now },
+	})
+
+	b.Trip()
+	now = now.Add(time.Second)
+
+	if !b.Allow() {
+		t.Fatal("expected to allow a probe after the cooldown")
+	}
+
+	now = now.Add(time.Second)
+
+	if !b.Allow() {
+		t.Fatal("expected to allow another probe when the first one did not report")
+	}
+}
+
+func TestConsecutiveHandler(t *testing.T) {
+	h := Handler(NewConsecutive(ConsecutiveConfig{Failures: 2}), DefaultStatusCodeValidator, code(500))
+
+	for _, want := range []int{500, 500, 503} {
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, &http.Request{Method: "GET"})
+
+		if got := resp.Code; got != want {
+			t.Fatalf("expected %d, got %d", want, got)
+		}
+	}
+}
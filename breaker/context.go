@@ -0,0 +1,131 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ContextBreaker is a Breaker that respects the cancellation of requests
+// and classifies the errors they end with.  Requests classified as Ignored
+// are reported to breakers implementing Ignorer, so they can free the place
+// the request held.
+type ContextBreaker interface {
+	Breaker
+
+	// AllowContext is like Allow, but returns false without taking up a
+	// request when ctx is done.
+	AllowContext(ctx context.Context) bool
+
+	// Report informs the circuit of the result of an allowed request, as
+	// Success when err is nil, otherwise depending on its classification.
+	Report(d time.Duration, err error)
+}
+
+// Outcome is how the result of a request counts to a breaker.
+type Outcome int
+
+const (
+	// Succeeded counts as Success.
+	Succeeded Outcome = iota
+
+	// Failed counts as Failure.
+	Failed
+
+	// Ignored counts as neither.
+	Ignored
+)
+
+// ErrorClassifier is a function that determines the Outcome of a request
+// that ended with an error. The DefaultErrorClassifier can be used in most
+// situations.
+type ErrorClassifier func(error) Outcome
+
+// DefaultErrorClassifier ignores requests canceled by the client, as they
+// say nothing about the health of the underlying resource. Any other error,
+// including an exceeded deadline, is a failure.
+func DefaultErrorClassifier(err error) Outcome {
+	switch {
+	case err == nil:
+		return Succeeded
+	case errors.Is(err, context.Canceled):
+		return Ignored
+	}
+	return Failed
+}
+
+// Ignorer is implemented by breakers holding a place for allowed requests,
+// like a half-open probe or the slot of a Bulkhead.  Ignore is called
+// instead of Success or Failure for requests classified as Ignored, so a
+// Breaker counting requests in flight should implement it to not leak the
+// places of canceled requests.
+type Ignorer interface {
+	Ignore()
+}
+
+// report signals the outcome of err to the breaker.  Ignored requests count
+// as neither Success nor Failure, but still free their place in breakers
+// limiting the requests in flight.
+func report(b Breaker, classify ErrorClassifier, d time.Duration, err error) {
+	outcome := Succeeded
+	if err != nil {
+		outcome = classify(err)
+	}
+
+	switch outcome {
+	case Succeeded:
+		b.Success(d)
+	case Failed:
+		b.Failure(d)
+	case Ignored:
+		if i, ok := b.(Ignorer); ok {
+			i.Ignore()
+		}
+	}
+}
+
+// WithContext adapts a Breaker into a ContextBreaker that classifies errors
+// with classify, or DefaultErrorClassifier when nil.
+func WithContext(b Breaker, classify ErrorClassifier) ContextBreaker {
+	if classify == nil {
+		classify = DefaultErrorClassifier
+	}
+	return &contextBreaker{Breaker: b, classify: classify}
+}
+
+type contextBreaker struct {
+	Breaker
+	classify ErrorClassifier
+}
+
+func (b *contextBreaker) AllowContext(ctx context.Context) bool {
+	return ctx.Err() == nil && b.Allow()
+}
+
+func (b *contextBreaker) Report(d time.Duration, err error) {
+	report(b.Breaker, b.classify, d, err)
+}
+
+// AllowContext is like Allow, but returns false without taking up a request
+// when ctx is done before the circuit answers.
+func (b *Circuit) AllowContext(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	b.start()
+	select {
+	case allow := <-b.allow:
+		return allow
+	case <-b.done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Report informs the circuit of the result of an allowed request classified
+// by the DefaultErrorClassifier.
+func (b *Circuit) Report(d time.Duration, err error) {
+	report(b, DefaultErrorClassifier, d, err)
+}
@@ -0,0 +1,200 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDefaultErrorClassifier(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Outcome
+	}{
+		{nil, Succeeded},
+		{errors.New("connection refused"), Failed},
+		{context.DeadlineExceeded, Failed},
+		{context.Canceled, Ignored},
+		{fmt.Errorf("dial: %w", context.Canceled), Ignored},
+	}
+
+	for _, tt := range tests {
+		if got := DefaultErrorClassifier(tt.err); got != tt.want {
+			t.Errorf("expected %v to be %d, got %d", tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestWithContextReportsClassifiedErrors(t *testing.T) {
+	b := WithContext(NewConsecutive(ConsecutiveConfig{Failures: 2}), nil)
+
+	b.Report(0, context.Canceled)
+	b.Report(0, context.Canceled)
+
+	if !b.Allow() {
+		t.Fatal("expected canceled requests to not count as failures")
+	}
+
+	b.Report(0, context.DeadlineExceeded)
+	b.Report(0, context.DeadlineExceeded)
+
+	if b.Allow() {
+		t.Fatal("expected exceeded deadlines to count as failures")
+	}
+}
+
+func TestAllowContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, b := range []ContextBreaker{
+		New(Config{}),
+		WithContext(NewSync(Config{}), nil),
+	} {
+		if b.AllowContext(ctx) {
+			t.Fatalf("expected %T to not allow a done context", b)
+		}
+
+		if !b.AllowContext(context.Background()) {
+			t.Fatalf("expected %T to allow", b)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransportIgnoresCanceledRequests(t *testing.T) {
+	b := NewConsecutive(ConsecutiveConfig{Failures: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rt := Transport(b, DefaultResponseValidator, roundTripFunc(func(*http.Request) (*http.Response, error) {
+		cancel()
+		return nil, errors.New("net/http: request canceled")
+	}))
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	if _, err := rt.RoundTrip(req.WithContext(ctx)); err == nil {
+		t.Fatal("expected the error of the canceled request")
+	}
+
+	if got, want := b.State(), Closed; got != want {
+		t.Fatalf("expected a canceled request to leave the circuit %s, got %s", want, got)
+	}
+
+	if _, err := rt.RoundTrip(req.WithContext(ctx)); err != context.Canceled {
+		t.Fatalf("expected %q for a done context, got %v", context.Canceled, err)
+	}
+}
+
+// inFlight is a Breaker outside of the package counting the requests in
+// flight.
+type inFlight struct{ n int }
+
+func (b *inFlight) Allow() bool           { b.n++; return true }
+func (b *inFlight) Success(time.Duration) { b.n-- }
+func (b *inFlight) Failure(time.Duration) { b.n-- }
+func (b *inFlight) Ignore()               { b.n-- }
+
+func TestTransportReportsCanceledRequestsToIgnorers(t *testing.T) {
+	b := &inFlight{}
+
+	rt := Transport(b, DefaultResponseValidator, roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return nil, context.Canceled
+	}))
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	rt.RoundTrip(req)
+
+	if b.n != 0 {
+		t.Fatalf("expected the canceled request to free its place, got %d in flight", b.n)
+	}
+}
+
+func TestTransportCountsExceededDeadlines(t *testing.T) {
+	b := NewConsecutive(ConsecutiveConfig{Failures: 1})
+
+	rt := Transport(b, DefaultResponseValidator, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	rt.RoundTrip(req.WithContext(ctx))
+
+	if got, want := b.State(), Open; got != want {
+		t.Fatalf("expected an exceeded deadline to open the circuit, got %s", got)
+	}
+}
+
+func TestCanceledProbeFreesHalfOpen(t *testing.T) {
+	var mu sync.Mutex
+	now := time.Now()
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+
+	after := make(chan time.Time, 1)
+	config := Config{
+		CooldownPolicy: ExponentialCooldown(time.Second, time.Minute),
+		Now:            clock,
+		After:          func(time.Duration) <-chan time.Time { return after },
+	}
+
+	type breaker interface {
+		Breaker
+		Trip()
+		State() State
+	}
+
+	circuit := New(config)
+	defer circuit.Close()
+
+	for _, b := range []breaker{
+		circuit,
+		NewSync(config),
+		NewConsecutive(ConsecutiveConfig{Now: config.Now}),
+	} {
+		cb := asContextBreaker(b)
+
+		b.Trip()
+		mu.Lock()
+		now = now.Add(time.Second)
+		mu.Unlock()
+		if b == circuit {
+			after <- clock()
+		}
+
+		if !cb.Allow() {
+			t.Fatalf("%T: expected to allow a probe after the cooldown", b)
+		}
+
+		cb.Report(0, context.Canceled)
+
+		if want, got := HalfOpen, b.State(); want != got {
+			t.Fatalf("%T: expected a canceled probe to stay %s, got %s", b, want, got)
+		}
+
+		if !cb.Allow() {
+			t.Fatalf("%T: expected a canceled probe to free its place", b)
+		}
+
+		cb.Report(0, nil)
+
+		if want, got := Closed, b.State(); want != got {
+			t.Fatalf("%T: want %s, got %s", b, want, got)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package breaker
+
+import (
+	"math/rand"
+	"time"
+)
+
+// CooldownPolicy returns how long a circuit stays open after tripping the
+// given number of times in a row, counting from 1 for the first trip after
+// it closed.  Trips counted in a row are those from half-open, when probing
+// the underlying resource failed again.
+//
+// The strategies follow the Delayers of the retry package, but return the
+// duration rather than sleeping for it.
+type CooldownPolicy func(trips uint) time.Duration
+
+// ConstantCooldown stays open for d regardless of the trips.
+func ConstantCooldown(d time.Duration) CooldownPolicy {
+	return func(uint) time.Duration {
+		return d
+	}
+}
+
+// ExponentialCooldown stays open for base * 2^(trips-1), doubling with every
+// trip in a row up to max.
+func ExponentialCooldown(base, max time.Duration) CooldownPolicy {
+	return func(trips uint) time.Duration {
+		d := base
+		for i := uint(1); i < trips && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d
+	}
+}
+
+// JitterCooldown shortens the cooldown of policy by a random fraction up to
+// jitter, normalized between 0.0 and 1.0, so circuits that tripped together
+// don't probe together.
+func JitterCooldown(policy CooldownPolicy, jitter float64) CooldownPolicy {
+	if jitter < 0.0 {
+		jitter = 0.0
+	}
+
+	if jitter > 1.0 {
+		jitter = 1.0
+	}
+
+	return func(trips uint) time.Duration {
+		d := policy(trips)
+		return d - time.Duration(rand.Float64()*jitter*float64(d))
+	}
+}
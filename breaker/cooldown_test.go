@@ -0,0 +1,112 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponentialCooldown(t *testing.T) {
+	policy := ExponentialCooldown(time.Second, 10*time.Second)
+
+	for trips, want := range []time.Duration{1: time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second} {
+		if trips == 0 {
+			continue
+		}
+		if got := policy(uint(trips)); got != want {
+			t.Errorf("expected cooldown %s after %d trips, got %s", want, trips, got)
+		}
+	}
+
+	if got, want := policy(1000), 10*time.Second; got != want {
+		t.Errorf("expected cooldown to be capped at %s, got %s", want, got)
+	}
+}
+
+func TestJitterCooldown(t *testing.T) {
+	policy := JitterCooldown(ConstantCooldown(time.Second), 0.5)
+
+	for i := 0; i < 100; i++ {
+		if got := policy(1); got < time.Second/2 || got > time.Second {
+			t.Fatalf("expected cooldown between 500ms and 1s, got %s", got)
+		}
+	}
+}
+
+func TestBreakerGrowsCooldownOnRepeatedTrips(t *testing.T) {
+	cooldowns := make(chan time.Duration)
+	after := make(chan time.Time)
+
+	b := New(Config{
+		CooldownPolicy: ExponentialCooldown(time.Second, time.Minute),
+		After: func(d time.Duration) <-chan time.Time {
+			cooldowns <- d
+			return after
+		},
+	})
+
+	go b.Trip()
+
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		if got := <-cooldowns; got != want {
+			t.Fatalf("expected cooldown %s, got %s", want, got)
+		}
+		after <- time.Now()
+		b.Failure(0)
+	}
+	<-cooldowns
+
+	b.Reset()
+	go b.Failure(0)
+
+	if got, want := <-cooldowns, time.Second; got != want {
+		t.Fatalf("expected cooldown %s after closing, got %s", want, got)
+	}
+}
+
+func TestSyncCircuitGrowsCooldownOnRepeatedTrips(t *testing.T) {
+	now := time.Now()
+	b := NewSync(Config{
+		CooldownPolicy: ExponentialCooldown(time.Second, time.Minute),
+		Now:            func() time.Time { return now },
+	})
+
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		b.Failure(0)
+		if got := b.RetryAfter(); got != want {
+			t.Fatalf("expected cooldown %s, got %s", want, got)
+		}
+		now = now.Add(want)
+	}
+
+	b.Reset()
+	b.Failure(0)
+
+	if got, want := b.RetryAfter(), time.Second; got != want {
+		t.Fatalf("expected cooldown %s after closing, got %s", want, got)
+	}
+}
+
+func TestConsecutiveGrowsCooldownOnRepeatedTrips(t *testing.T) {
+	now := time.Now()
+	b := NewConsecutive(ConsecutiveConfig{
+		Failures:       1,
+		CooldownPolicy: ExponentialCooldown(time.Second, time.Minute),
+		Now:            func() time.Time { return now },
+	})
+
+	for _, want := range []time.Duration{time.Second, 2 * time.Second} {
+		b.Failure(0)
+		if got := b.RetryAfter(); got != want {
+			t.Fatalf("expected cooldown %s, got %s", want, got)
+		}
+		now = now.Add(want)
+		b.Allow()
+	}
+
+	b.Success(0)
+	b.Failure(0)
+
+	if got, want := b.RetryAfter(), time.Second; got != want {
+		t.Fatalf("expected cooldown %s after closing, got %s", want, got)
+	}
+}
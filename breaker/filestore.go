@@ -0,0 +1,114 @@
+package breaker
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultLockTimeout is the default time a FileStore waits for the lock of
+// a record.
+const DefaultLockTimeout = time.Second
+
+// ErrLockTimeout is returned by a FileStore when the lock of a record is not
+// acquired within its LockTimeout.
+var ErrLockTimeout = errors.New("breaker: timeout acquiring record lock")
+
+// FileStore is a Store keeping each record as a JSON file in a directory, so
+// co-located processes can share breakers, for example through a tmpfs like
+// /dev/shm.  Updates are serialized with an advisory lock on a file next to
+// the record, which the operating system releases when a process dies.
+type FileStore struct {
+	dir string
+
+	// LockTimeout is how long an Update waits for the lock of the record
+	// before failing with ErrLockTimeout, defaulting to DefaultLockTimeout.
+	LockTimeout time.Duration
+}
+
+// NewFileStore constructs a FileStore in dir, creating the directory when it
+// does not exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir, LockTimeout: DefaultLockTimeout}, nil
+}
+
+func (s *FileStore) path(key string) string {
+	return filepath.Join(s.dir, url.PathEscape(key)+".json")
+}
+
+// Load reads the record of key.
+func (s *FileStore) Load(key string) (Record, error) {
+	return s.read(s.path(key))
+}
+
+// Update applies f to the record of key while holding its lock.
+func (s *FileStore) Update(key string, f func(*Record)) (Record, error) {
+	path := s.path(key)
+
+	timeout := s.LockTimeout
+	if timeout <= 0 {
+		timeout = DefaultLockTimeout
+	}
+
+	unlock, err := lockFile(path+".lock", timeout)
+	if err != nil {
+		return Record{}, err
+	}
+	defer unlock()
+
+	r, err := s.read(path)
+	if err != nil {
+		return Record{}, err
+	}
+
+	f(&r)
+
+	return r, s.write(path, r)
+}
+
+func (s *FileStore) read(path string) (Record, error) {
+	var r Record
+
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return r, err
+	}
+
+	return r, json.Unmarshal(buf, &r)
+}
+
+// write replaces the record atomically so concurrent reads never see a
+// partial file.
+func (s *FileStore) write(path string, r Record) error {
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(s.dir, ".record")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package breaker
+
+import (
+	"errors"
+	"runtime"
+	"time"
+)
+
+// lockFile fails as advisory file locks are not supported on this platform.
+func lockFile(string, time.Duration) (func(), error) {
+	return nil, errors.New("breaker: file locks are not supported on " + runtime.GOOS)
+}
@@ -0,0 +1,118 @@
+//go:build unix
+
+package breaker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func tempFileStore(t *testing.T) *FileStore {
+	dir, err := ioutil.TempDir("", "breaker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	s, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	s := tempFileStore(t)
+	until := time.Unix(1, 0).UTC()
+
+	r, err := s.Load("example.com:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Trips != 0 || !r.Until.IsZero() {
+		t.Fatalf("expected an empty record, got %+v", r)
+	}
+
+	if _, err := s.Update("example.com:443", func(r *Record) {
+		r.Counts = append(r.Counts, Count{Bucket: 1, Failure: 2})
+		r.Until = until
+		r.Trips = 1
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = s.Load("example.com:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := (Summary{Total: 2, Errors: 2, Rate: 1}), r.Summary(); want != got {
+		t.Fatalf("want %+v, got %+v", want, got)
+	}
+
+	if !r.Until.Equal(until) || r.Trips != 1 {
+		t.Fatalf("expected the stored record, got %+v", r)
+	}
+}
+
+func TestFileStoreSerializesUpdates(t *testing.T) {
+	s := tempFileStore(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Update("key", func(r *Record) { r.Trips++ })
+		}()
+	}
+	wg.Wait()
+
+	r, err := s.Load("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := uint(20), r.Trips; want != got {
+		t.Fatalf("want %d updates, got %d", want, got)
+	}
+}
+
+func TestFileStoreLockTimeout(t *testing.T) {
+	s := tempFileStore(t)
+	s.LockTimeout = 10 * time.Millisecond
+
+	unlock, err := lockFile(filepath.Join(s.dir, "key.json.lock"), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Update("key", func(r *Record) { r.Trips++ }); err != ErrLockTimeout {
+		t.Fatalf("want %v while locked, got %v", ErrLockTimeout, err)
+	}
+
+	unlock()
+
+	if _, err := s.Update("key", func(r *Record) { r.Trips++ }); err != nil {
+		t.Fatalf("want the lock once released, got %v", err)
+	}
+}
+
+func TestSharedAcrossFileStores(t *testing.T) {
+	a := tempFileStore(t)
+	b, err := NewFileStore(a.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	NewShared(a, "upstream", Config{}).Trip()
+
+	if NewShared(b, "upstream", Config{}).Allow() {
+		t.Fatal("expected a trip through one store to open the circuit of the other")
+	}
+}
@@ -0,0 +1,44 @@
+//go:build unix
+
+package breaker
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// lockFile acquires an exclusive advisory lock on the file at path, waiting
+// up to timeout for other holders.  The file is kept so every process locks
+// the same inode.
+func lockFile(path string, timeout time.Duration) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	fd := int(f.Fd())
+	deadline := time.Now().Add(timeout)
+
+	for {
+		err := syscall.Flock(fd, syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return func() {
+				syscall.Flock(fd, syscall.LOCK_UN)
+				f.Close()
+			}, nil
+		}
+
+		if err != syscall.EWOULDBLOCK && err != syscall.EINTR {
+			f.Close()
+			return nil, err
+		}
+
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, ErrLockTimeout
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -2,6 +2,7 @@ package breaker
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -10,68 +11,178 @@ import (
 // failure. The DefaultStatusCodeValidator can be used in most situations.
 type StatusCodeValidator func(int) bool
 
+// HandlerConfig parameterizes a circuit breaking http.Handler made by
+// NewHandler or NewMiddleware.
+type HandlerConfig struct {
+	// Validator determines whether the status code of a response counts as
+	// a success, defaulting to DefaultStatusCodeValidator.
+	Validator StatusCodeValidator
+
+	// Fallback serves incoming requests once the breaker opens, for example
+	// from a cache of stale content.  When nil, requests are answered with
+	// HTTP 503 and a Retry-After header when the breaker has a RetryAfter
+	// method like Circuit.
+	Fallback http.Handler
+
+	// FirstByte reports the time until the next http.Handler wrote the header
+	// or first byte of the response as the duration of the request, rather
+	// than the time until it returned, so streaming responses aren't judged
+	// slow by their length.
+	FirstByte bool
+}
+
 // Middleware produces an http.Handler factory like Handler to be composed.
 func Middleware(breaker Breaker, validator StatusCodeValidator) func(http.Handler) http.Handler {
+	return NewMiddleware(breaker, HandlerConfig{Validator: validator})
+}
+
+// FallbackMiddleware produces an http.Handler factory like FallbackHandler
+// to be composed.
+func FallbackMiddleware(breaker Breaker, validator StatusCodeValidator, fallback http.Handler) func(http.Handler) http.Handler {
+	return NewMiddleware(breaker, HandlerConfig{Validator: validator, Fallback: fallback})
+}
+
+// NewMiddleware produces an http.Handler factory like NewHandler to be
+// composed.
+func NewMiddleware(breaker Breaker, config HandlerConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
-		return Handler(breaker, validator, next)
+		return NewHandler(breaker, config, next)
 	}
 }
 
 // Handler produces an http.Handler that's governed by the passed Breaker and
 // StatusCodeValidator. Responses written by the next http.Handler whose
-// status codes fail the validator signal failures to the breaker. Once the
-// breaker opens, incoming requests are terminated before being forwarded with
-// HTTP 503.
+// status codes fail the validator signal failures to the breaker, as do
+// panics, which are passed on, and requests whose client went away before
+// the next http.Handler returned. Once the breaker opens, incoming requests
+// are terminated before being forwarded with HTTP 503, with a Retry-After
+// header when the breaker has a RetryAfter method like Circuit.
 func Handler(breaker Breaker, validator StatusCodeValidator, next http.Handler) http.Handler {
+	return NewHandler(breaker, HandlerConfig{Validator: validator}, next)
+}
+
+// FallbackHandler produces an http.Handler like Handler that serves incoming
+// requests with the fallback http.Handler once the breaker opens.  A nil
+// fallback responds like Handler.
+func FallbackHandler(breaker Breaker, validator StatusCodeValidator, fallback, next http.Handler) http.Handler {
+	return NewHandler(breaker, HandlerConfig{Validator: validator, Fallback: fallback}, next)
+}
+
+// NewHandler produces an http.Handler like Handler configured by config, for
+// example to serve a fallback once the breaker opens or to time requests
+// until their first byte.
+func NewHandler(breaker Breaker, config HandlerConfig, next http.Handler) http.Handler {
+	if config.Validator == nil {
+		config.Validator = DefaultStatusCodeValidator
+	}
+
 	return &handler{
 		breaker:   breaker,
-		validator: validator,
+		validator: config.Validator,
+		fallback:  config.Fallback,
 		next:      next,
+		firstByte: config.FirstByte,
 	}
 }
 
 type handler struct {
 	breaker   Breaker
 	validator StatusCodeValidator
+	fallback  http.Handler
 	next      http.Handler
+	firstByte bool
 }
 
 func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if h.breaker.Allow() {
+	if h.allow(r) {
 		h.serveClosed(w, r)
 	} else {
 		h.serveOpened(w, r)
 	}
 }
 
+// allow stops waiting for a ContextBreaker like a Bulkhead when the client
+// goes away.
+func (h *handler) allow(r *http.Request) bool {
+	if b, ok := h.breaker.(ContextBreaker); ok {
+		return b.AllowContext(r.Context())
+	}
+	return h.breaker.Allow()
+}
+
+// serveClosed reports the request as a failure when the next handler panics
+// or the client went away before it returned, as neither status code
+// reflects the health of the handler.  Panics are passed on.
 func (h *handler) serveClosed(w http.ResponseWriter, r *http.Request) {
-	cw := &codeWriter{w, 200}
+	cw := &codeWriter{ResponseWriter: w, code: 200}
 	begin := time.Now()
 
-	h.next.ServeHTTP(cw, r)
+	defer func() {
+		duration := time.Since(begin)
+		if h.firstByte && !cw.first.IsZero() {
+			duration = cw.first.Sub(begin)
+		}
 
-	duration := time.Since(begin)
-	if h.validator(cw.code) {
-		h.breaker.Success(duration)
-	} else {
-		h.breaker.Failure(duration)
-	}
+		if p := recover(); p != nil {
+			h.breaker.Failure(duration)
+			panic(p)
+		}
+
+		if r.Context().Err() == nil && h.validator(cw.code) {
+			h.breaker.Success(duration)
+		} else {
+			h.breaker.Failure(duration)
+		}
+	}()
+
+	h.next.ServeHTTP(cw, r)
 }
 
 func (h *handler) serveOpened(w http.ResponseWriter, r *http.Request) {
+	if h.fallback != nil {
+		h.fallback.ServeHTTP(w, r)
+		return
+	}
+
+	if b, ok := h.breaker.(interface{ RetryAfter() time.Duration }); ok {
+		w.Header().Set("Retry-After", retryAfter(b.RetryAfter()))
+	}
 	w.WriteHeader(http.StatusServiceUnavailable)
 }
 
+// retryAfter formats the duration in whole seconds rounded up, at least 1
+// as the circuit opened and 0 would invite an immediate retry.
+func retryAfter(d time.Duration) string {
+	seconds := int64((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.FormatInt(seconds, 10)
+}
+
 type codeWriter struct {
 	http.ResponseWriter
-	code int
+	code  int
+	first time.Time // of the header or first byte written
 }
 
 func (w *codeWriter) WriteHeader(code int) {
 	w.code = code
+	w.wrote()
 	w.ResponseWriter.WriteHeader(code)
 }
 
+func (w *codeWriter) Write(p []byte) (int, error) {
+	w.wrote()
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *codeWriter) wrote() {
+	if w.first.IsZero() {
+		w.first = time.Now()
+	}
+}
+
 // DefaultStatusCodeValidator considers any status code less than 500 to be a
 // success, from the perspective of a server. All other codes are failures.
 func DefaultStatusCodeValidator(code int) bool {
@@ -1,9 +1,11 @@
 package breaker
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 type code int
@@ -52,3 +54,143 @@ func TestHandlerCircuitOpenWith5PercentError(t *testing.T) {
 		t.Fatalf("expected circuit to be open with 503 after 5%% error rate, got last response: %d", lastResponse)
 	}
 }
+
+func TestHandlerSetsRetryAfterWhenOpen(t *testing.T) {
+	now := time.Now()
+	b := NewSync(Config{
+		Cooldown: 10 * time.Second,
+		Now:      func() time.Time { return now },
+	})
+	b.Trip()
+	now = now.Add(2500 * time.Millisecond)
+
+	resp := httptest.NewRecorder()
+	Handler(b, DefaultStatusCodeValidator, code(200)).ServeHTTP(resp, &http.Request{Method: "GET"})
+
+	if got, want := resp.Code, http.StatusServiceUnavailable; got != want {
+		t.Fatalf("expected %d when open, got %d", want, got)
+	}
+
+	if got, want := resp.Header().Get("Retry-After"), "8"; got != want {
+		t.Fatalf("expected Retry-After %q, got %q", want, got)
+	}
+}
+
+func TestHandlerServesFallbackWhenOpen(t *testing.T) {
+	b := NewSync(Config{})
+	b.Trip()
+
+	fallback := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("stale"))
+	})
+
+	resp := httptest.NewRecorder()
+	FallbackHandler(b, DefaultStatusCodeValidator, fallback, code(500)).ServeHTTP(resp, &http.Request{Method: "GET"})
+
+	if got, want := resp.Code, http.StatusOK; got != want {
+		t.Fatalf("expected fallback status %d, got %d", want, got)
+	}
+
+	if got, want := resp.Body.String(), "stale"; got != want {
+		t.Fatalf("expected fallback body %q, got %q", want, got)
+	}
+
+	if got := resp.Header().Get("Retry-After"); got != "" {
+		t.Fatalf("expected no Retry-After from the fallback, got %q", got)
+	}
+}
+
+type recorded struct {
+	successes, failures []time.Duration
+}
+
+func (b *recorded) Allow() bool             { return true }
+func (b *recorded) Success(d time.Duration) { b.successes = append(b.successes, d) }
+func (b *recorded) Failure(d time.Duration) { b.failures = append(b.failures, d) }
+
+func TestHandlerCountsPanicsAsFailures(t *testing.T) {
+	b := &recorded{}
+	h := Handler(b, DefaultStatusCodeValidator, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Fatalf("expected the panic to be passed on, got %v", p)
+		}
+
+		if want, got := 1, len(b.failures); want != got {
+			t.Fatalf("want %d failures, got %d", want, got)
+		}
+	}()
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestHandlerCountsCanceledRequestsAsFailures(t *testing.T) {
+	b := &recorded{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	h := Handler(b, DefaultStatusCodeValidator, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		cancel()
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+
+	if want, got := 1, len(b.failures); want != got {
+		t.Fatalf("want %d failures, got %d", want, got)
+	}
+
+	if want, got := 0, len(b.successes); want != got {
+		t.Fatalf("want %d successes, got %d", want, got)
+	}
+}
+
+func TestHandlerReportsTimeToFirstByte(t *testing.T) {
+	b := &recorded{}
+	h := NewHandler(b, HandlerConfig{FirstByte: true}, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("first"))
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("last"))
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if want, got := 1, len(b.successes); want != got {
+		t.Fatalf("want %d successes, got %d", want, got)
+	}
+
+	if d := b.successes[0]; d >= 50*time.Millisecond {
+		t.Fatalf("expected the time to first byte, got %s", d)
+	}
+}
+
+func TestMiddlewareServesFallbackAndTimesFirstByte(t *testing.T) {
+	b := &recorded{}
+	fallback := code(http.StatusNoContent)
+
+	h := NewMiddleware(b, HandlerConfig{Fallback: fallback, FirstByte: true})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		time.Sleep(50 * time.Millisecond)
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if want, got := 1, len(b.failures); want != got {
+		t.Fatalf("want %d failures by the default validator, got %d", want, got)
+	}
+
+	if d := b.failures[0]; d >= 50*time.Millisecond {
+		t.Fatalf("expected the time to first byte, got %s", d)
+	}
+
+	open := NewSync(Config{})
+	open.Trip()
+
+	resp := httptest.NewRecorder()
+	NewMiddleware(open, HandlerConfig{Fallback: fallback, FirstByte: true})(code(200)).ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+
+	if want, got := http.StatusNoContent, resp.Code; want != got {
+		t.Fatalf("expected the fallback status %d when open, got %d", want, got)
+	}
+}
@@ -7,43 +7,55 @@ import (
 )
 
 type counter struct {
-	second  int64
+	bucket  int64
 	success uint
 	failure uint
+	slow    uint
 }
 
-func (c *counter) reset(second int64) {
+func (c *counter) reset(bucket int64) {
 	c.failure = 0
 	c.success = 0
-	c.second = second
+	c.slow = 0
+	c.bucket = bucket
 }
 
-type summary struct {
-	total  uint
-	errors uint
-	rate   float64
+// Summary is the count of observations and failures made over the window
+// of a circuit breaker.
+type Summary struct {
+	Total    uint    // observations
+	Errors   uint    // failures
+	Rate     float64 // failures per observation
+	Slow     uint    // observations taking at least the slow call duration
+	SlowRate float64 // slow observations per observation
 }
 
 type metric struct {
 	r       *ring.Ring
-	seconds uint
+	width   time.Duration // of each bucket
+	buckets uint
 	now     func() time.Time
+	slow    time.Duration // observations are slow from this duration, 0 disables
 }
 
-func newMetric(window time.Duration, now func() time.Time) *metric {
-	seconds := int(window / time.Second)
+func newMetric(window, width time.Duration, now func() time.Time) *metric {
+	if width <= 0 {
+		panic("metrics must have buckets of a positive width")
+	}
+
+	buckets := int(window / width)
 
-	if seconds <= 0 {
-		panic("metrics must have a window of at least 1 Second")
+	if buckets <= 0 {
+		panic("metrics must have a window of at least 1 bucket")
 	}
 
-	r := ring.New(seconds)
-	for i := 0; i < seconds; i++ {
+	r := ring.New(buckets)
+	for i := 0; i < buckets; i++ {
 		r.Value = &counter{}
 		r = r.Next()
 	}
 
-	return &metric{r: r, seconds: uint(seconds), now: now}
+	return &metric{r: r, width: width, buckets: uint(buckets), now: now}
 }
 
 func (m *metric) String() string {
@@ -53,13 +65,13 @@ func (m *metric) String() string {
 }
 
 func (m *metric) next() *counter {
-	bucket := m.now().Unix()
+	bucket := m.now().UnixNano() / int64(m.width)
 	c := m.r.Value.(*counter)
-	if c.second != bucket {
-		step := bucket - c.second
+	if c.bucket != bucket {
+		step := bucket - c.bucket
 		// consider the data are invalid when clock jumps back
-		if step < 0 || step > int64(m.seconds) {
-			step = int64(m.seconds)
+		if step < 0 || step > int64(m.buckets) {
+			step = int64(m.buckets)
 		}
 
 		for i := int64(1); i <= step; i++ {
@@ -71,25 +83,37 @@ func (m *metric) next() *counter {
 	return c
 }
 
-func (m *metric) Success(time.Duration) {
-	m.next().success++
+func (m *metric) Success(d time.Duration) {
+	c := m.next()
+	c.success++
+	m.observe(c, d)
 }
 
-func (m *metric) Failure(time.Duration) {
-	m.next().failure++
+func (m *metric) Failure(d time.Duration) {
+	c := m.next()
+	c.failure++
+	m.observe(c, d)
+}
+
+func (m *metric) observe(c *counter, d time.Duration) {
+	if m.slow > 0 && d >= m.slow {
+		c.slow++
+	}
 }
 
-func (m metric) Summary() summary {
-	var sum summary
+func (m metric) Summary() Summary {
+	var sum Summary
 
 	m.r.Do(func(v interface{}) {
 		c := v.(*counter)
-		sum.total += c.success + c.failure
-		sum.errors += c.failure
+		sum.Total += c.success + c.failure
+		sum.Errors += c.failure
+		sum.Slow += c.slow
 	})
 
-	if sum.total > 0 {
-		sum.rate = float64(sum.errors) / float64(sum.total)
+	if sum.Total > 0 {
+		sum.Rate = float64(sum.Errors) / float64(sum.Total)
+		sum.SlowRate = float64(sum.Slow) / float64(sum.Total)
 	}
 
 	return sum
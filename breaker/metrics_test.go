@@ -6,7 +6,7 @@ import (
 )
 
 func TestErrorRateUnderThreshold(t *testing.T) {
-	c := newMetric(5*time.Second, time.Now)
+	c := newMetric(5*time.Second, time.Second, time.Now)
 
 	c.Success(0)
 	c.Success(0)
@@ -17,13 +17,13 @@ func TestErrorRateUnderThreshold(t *testing.T) {
 	c.Success(0)
 	c.Success(0)
 
-	if r := c.Summary().rate; r == 0.0 {
+	if r := c.Summary().Rate; r == 0.0 {
 		t.Errorf("expected error rate to be greater than zero,  got: %f in %+v", r, c.Summary())
 	}
 }
 
 func TestErrorRateOverThreshold(t *testing.T) {
-	c := newMetric(5*time.Second, time.Now)
+	c := newMetric(5*time.Second, time.Second, time.Now)
 
 	c.Failure(0)
 	c.Failure(0)
@@ -35,14 +35,14 @@ func TestErrorRateOverThreshold(t *testing.T) {
 	c.Success(0)
 	c.Success(0)
 
-	if ex, s := 0.70, c.Summary(); s.rate < ex {
-		t.Errorf("expected error rate to be over %d%%, got: %f in %+v", int(ex*100), s.rate, s)
+	if ex, s := 0.70, c.Summary(); s.Rate < ex {
+		t.Errorf("expected error rate to be over %d%%, got: %f in %+v", int(ex*100), s.Rate, s)
 	}
 }
 
 func TestErrorRateCalculatedFromLast5Seconds(t *testing.T) {
 	fakenow := time.Now()
-	c := newMetric(5*time.Second, func() time.Time { return fakenow })
+	c := newMetric(5*time.Second, time.Second, func() time.Time { return fakenow })
 
 	// 77% error for 5 seconds
 	for i := 0; i < 5; i++ {
@@ -62,15 +62,15 @@ func TestErrorRateCalculatedFromLast5Seconds(t *testing.T) {
 		c.Success(0)
 	}
 
-	if ex, s := 0.34, c.Summary(); s.rate > ex {
-		t.Errorf("expected error rate to be under %d%%, got: %f in %+v", int(ex*100), s.rate, s)
+	if ex, s := 0.34, c.Summary(); s.Rate > ex {
+		t.Errorf("expected error rate to be under %d%%, got: %f in %+v", int(ex*100), s.Rate, s)
 	}
 
 }
 
 func TestErrorRateCalculationWithTimeGap(t *testing.T) {
 	fakenow := time.Now()
-	c := newMetric(3*time.Second, func() time.Time { return fakenow })
+	c := newMetric(3*time.Second, time.Second, func() time.Time { return fakenow })
 
 	for i := 0; i < 3; i++ {
 		fakenow = fakenow.Add(time.Second)
@@ -81,11 +81,11 @@ func TestErrorRateCalculationWithTimeGap(t *testing.T) {
 	fakenow = fakenow.Add(4 * time.Second)
 	c.Success(0)
 
-	if ex, s := 0.0, c.Summary(); s.rate != ex {
-		t.Errorf("expected error rate to be %d%%, got: %f in %+v", int(ex*100), s.rate, s)
+	if ex, s := 0.0, c.Summary(); s.Rate != ex {
+		t.Errorf("expected error rate to be %d%%, got: %f in %+v", int(ex*100), s.Rate, s)
 	}
 
-	c = newMetric(3*time.Second, func() time.Time { return fakenow })
+	c = newMetric(3*time.Second, time.Second, func() time.Time { return fakenow })
 	for i := 0; i < 2; i++ {
 		fakenow = fakenow.Add(time.Second)
 		c.Failure(0)
@@ -95,11 +95,11 @@ func TestErrorRateCalculationWithTimeGap(t *testing.T) {
 	fakenow = fakenow.Add(2 * time.Second)
 	c.Success(0)
 
-	if ex, s := 0.5, c.Summary(); s.rate != ex {
-		t.Errorf("expected error rate to be %d%%, got: %f in %+v", int(ex*100), s.rate, s)
+	if ex, s := 0.5, c.Summary(); s.Rate != ex {
+		t.Errorf("expected error rate to be %d%%, got: %f in %+v", int(ex*100), s.Rate, s)
 	}
 
-	c = newMetric(3*time.Second, func() time.Time { return fakenow })
+	c = newMetric(3*time.Second, time.Second, func() time.Time { return fakenow })
 	for i := 0; i < 3; i++ {
 		fakenow = fakenow.Add(time.Second)
 		c.Success(0)
@@ -109,7 +109,59 @@ func TestErrorRateCalculationWithTimeGap(t *testing.T) {
 	fakenow = fakenow.Add(-time.Second)
 	c.Failure(0)
 
-	if ex, s := 1.0, c.Summary(); s.rate != ex {
-		t.Errorf("expected error rate to be %d%%, got: %f in %+v", int(ex*100), s.rate, s)
+	if ex, s := 1.0, c.Summary(); s.Rate != ex {
+		t.Errorf("expected error rate to be %d%%, got: %f in %+v", int(ex*100), s.Rate, s)
 	}
 }
+
+func TestSlowRate(t *testing.T) {
+	c := newMetric(5*time.Second, time.Second, time.Now)
+	c.slow = time.Second
+
+	c.Success(0)
+	c.Success(2 * time.Second)
+	c.Failure(time.Second)
+	c.Failure(time.Millisecond)
+
+	if ex, s := (Summary{Total: 4, Errors: 2, Rate: 0.5, Slow: 2, SlowRate: 0.5}), c.Summary(); s != ex {
+		t.Errorf("expected summary %+v, got %+v", ex, s)
+	}
+}
+
+func TestErrorRateWithSubSecondBuckets(t *testing.T) {
+	fakenow := time.Now()
+	c := newMetric(2*time.Second, 100*time.Millisecond, func() time.Time { return fakenow })
+
+	for i := 0; i < 20; i++ {
+		fakenow = fakenow.Add(100 * time.Millisecond)
+		c.Failure(0)
+	}
+
+	// the failures expire a bucket at a time
+	for i := 0; i < 10; i++ {
+		fakenow = fakenow.Add(100 * time.Millisecond)
+		c.Success(0)
+	}
+
+	if ex, s := (Summary{Total: 20, Errors: 10, Rate: 0.5}), c.Summary(); s != ex {
+		t.Errorf("expected summary %+v, got %+v", ex, s)
+	}
+
+	// clock jumps back
+	fakenow = fakenow.Add(-300 * time.Millisecond)
+	c.Failure(0)
+
+	if ex, s := (Summary{Total: 1, Errors: 1, Rate: 1}), c.Summary(); s != ex {
+		t.Errorf("expected summary %+v after the clock jumped back, got %+v", ex, s)
+	}
+}
+
+func TestNewMetricPanicsWithoutBuckets(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a window narrower than a bucket to panic")
+		}
+	}()
+
+	newMetric(50*time.Millisecond, 100*time.Millisecond, time.Now)
+}
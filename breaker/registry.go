@@ -0,0 +1,129 @@
+package breaker
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultIdle is the default period a breaker of a Registry may go unused
+// before it is evicted.
+const DefaultIdle = 10 * time.Minute
+
+// Registry lazily creates a Breaker per key, like the host of an upstream,
+// so the failures of one key don't open the circuit for the others.  Breakers
+// unused for the idle period are evicted, and closed when they implement
+// io.Closer.  It is safe for concurrent use.
+type Registry struct {
+	factory func(key string) Breaker
+	idle    time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	swept   time.Time
+}
+
+type entry struct {
+	breaker Breaker
+	used    time.Time
+}
+
+// NewRegistry constructs a registry creating breakers with factory and
+// evicting those unused for idle, or DefaultIdle when 0.
+func NewRegistry(factory func(key string) Breaker, idle time.Duration) *Registry {
+	if idle == 0 {
+		idle = DefaultIdle
+	}
+
+	return &Registry{
+		factory: factory,
+		idle:    idle,
+		now:     time.Now,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Get returns the breaker for key, creating it on first use.
+func (r *Registry) Get(key string) Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.sweep(now)
+
+	e, ok := r.entries[key]
+	if !ok {
+		e = &entry{breaker: r.factory(key)}
+		r.entries[key] = e
+	}
+	e.used = now
+
+	return e.breaker
+}
+
+// Each calls f with every breaker in the registry and its key.  The registry
+// is locked while f runs, so f must not call back into it.
+func (r *Registry) Each(f func(key string, b Breaker)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, e := range r.entries {
+		f(key, e.breaker)
+	}
+}
+
+// sweep evicts the idle breakers at most once per idle period, so eviction
+// needs no goroutine of its own.
+func (r *Registry) sweep(now time.Time) {
+	if now.Sub(r.swept) < r.idle {
+		return
+	}
+	r.swept = now
+
+	for key, e := range r.entries {
+		if now.Sub(e.used) >= r.idle {
+			delete(r.entries, key)
+			if c, ok := e.breaker.(io.Closer); ok {
+				c.Close()
+			}
+		}
+	}
+}
+
+// KeyFunc is a function that determines the key of the breaker governing a
+// request.  The DefaultKeyFunc can be used in most situations.
+type KeyFunc func(*http.Request) string
+
+// DefaultKeyFunc keys requests by the host of their URL, including the port.
+func DefaultKeyFunc(req *http.Request) string {
+	return req.URL.Host
+}
+
+// RegistryTransport produces an http.RoundTripper like Transport that is
+// governed by the Breaker from the registry for the key of each request, as
+// determined by key, or DefaultKeyFunc when nil.
+func RegistryTransport(registry *Registry, key KeyFunc, validator ResponseValidator, next http.RoundTripper) http.RoundTripper {
+	if key == nil {
+		key = DefaultKeyFunc
+	}
+
+	return &registryTransport{
+		registry:  registry,
+		key:       key,
+		validator: validator,
+		next:      next,
+	}
+}
+
+type registryTransport struct {
+	registry  *Registry
+	key       KeyFunc
+	validator ResponseValidator
+	next      http.RoundTripper
+}
+
+func (t *registryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return roundTrip(asContextBreaker(t.registry.Get(t.key(req))), t.validator, t.next, req)
+}
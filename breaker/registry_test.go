@@ -0,0 +1,86 @@
+package breaker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRegistryCreatesBreakerPerKey(t *testing.T) {
+	created := 0
+	r := NewRegistry(func(string) Breaker {
+		created++
+		return NewSync(Config{})
+	}, 0)
+
+	a, b := r.Get("a"), r.Get("b")
+
+	if a == b {
+		t.Fatal("expected a breaker per key")
+	}
+
+	if r.Get("a") != a {
+		t.Fatal("expected the same breaker for the same key")
+	}
+
+	if created != 2 {
+		t.Fatalf("expected 2 breakers to be created, got %d", created)
+	}
+}
+
+func TestRegistryEvictsIdleBreakers(t *testing.T) {
+	now := time.Now()
+	r := NewRegistry(func(string) Breaker { return New(Config{}) }, time.Minute)
+	r.now = func() time.Time { return now }
+
+	idle := r.Get("idle").(*Circuit)
+	used := r.Get("used")
+
+	now = now.Add(30 * time.Second)
+	r.Get("used")
+
+	now = now.Add(45 * time.Second)
+
+	if r.Get("used") != used {
+		t.Fatal("expected a used breaker to not be evicted")
+	}
+
+	if r.Get("idle") == Breaker(idle) {
+		t.Fatal("expected an idle breaker to be evicted")
+	}
+
+	select {
+	case <-idle.done:
+	default:
+		t.Fatal("expected an evicted breaker to be closed")
+	}
+}
+
+func TestRegistryTransportIsolatesHosts(t *testing.T) {
+	pass := httptest.NewServer(code(200))
+	defer pass.Close()
+
+	fail := httptest.NewServer(code(500))
+	defer fail.Close()
+
+	r := NewRegistry(func(string) Breaker {
+		return NewConsecutive(ConsecutiveConfig{Failures: 1})
+	}, 0)
+
+	c := http.Client{
+		Transport: RegistryTransport(r, nil, DefaultResponseValidator, http.DefaultTransport),
+	}
+
+	c.Get(fail.URL)
+
+	if _, err := c.Get(fail.URL); err == nil {
+		t.Fatal("expected the circuit of the failing host to be open")
+	}
+
+	resp, err := c.Get(pass.URL)
+	if err != nil {
+		t.Fatalf("expected the circuit of the passing host to be closed, got %v", err)
+	}
+	resp.Body.Close()
+}
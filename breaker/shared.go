@@ -0,0 +1,212 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// Shared is a Breaker keeping its observations and state in a Store under a
+// key, so every Shared breaker of the same key and store, like those of
+// replicas sharing a FileStore, opens and closes together.  Like a
+// Consecutive breaker it allows a single probe across all of them once the
+// cooldown expires, and another one only when the probe has not reported
+// back within a cooldown.
+//
+// The FailureRatio, Window, Bucket, Cooldown, CooldownPolicy,
+// MinObservations and Now of the Config apply, the half-open and slow call
+// settings do not.
+//
+// When the store fails the breaker allows requests, and Err returns the
+// failure.  It is safe for concurrent use.
+type Shared struct {
+	store  Store
+	key    string
+	config Config
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewShared constructs a breaker sharing the record of key in store.  Unset
+// durations and clocks of the config take their defaults.
+func NewShared(store Store, key string, c Config) *Shared {
+	return &Shared{
+		store:  store,
+		key:    key,
+		config: c.withDefaults(),
+	}
+}
+
+// Err returns the error of the last failed access to the store, or nil when
+// the last access succeeded.
+func (b *Shared) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.err
+}
+
+func (b *Shared) setErr(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.err = err
+}
+
+func (b *Shared) load() (Record, error) {
+	r, err := b.store.Load(b.key)
+	b.setErr(err)
+	return r, err
+}
+
+func (b *Shared) update(f func(r *Record, now time.Time)) (Record, error) {
+	r, err := b.store.Update(b.key, func(r *Record) {
+		now := b.config.Now()
+		b.expire(r, now)
+		f(r, now)
+	})
+	b.setErr(err)
+	return r, err
+}
+
+// bucket returns the bucket of the window at now.
+func (b *Shared) bucket(now time.Time) int64 {
+	return now.UnixNano() / int64(b.config.Bucket)
+}
+
+// expire drops the counts of the buckets that left the window.
+func (b *Shared) expire(r *Record, now time.Time) {
+	oldest := b.bucket(now) - int64(b.config.Window/b.config.Bucket)
+
+	counts := r.Counts[:0]
+	for _, c := range r.Counts {
+		if c.Bucket > oldest {
+			counts = append(counts, c)
+		}
+	}
+	r.Counts = counts
+}
+
+// count returns the count of the current bucket.
+func (b *Shared) count(r *Record, now time.Time) *Count {
+	bucket := b.bucket(now)
+	if n := len(r.Counts); n == 0 || r.Counts[n-1].Bucket != bucket {
+		r.Counts = append(r.Counts, Count{Bucket: bucket})
+	}
+	return &r.Counts[len(r.Counts)-1]
+}
+
+func (b *Shared) trip(r *Record, now time.Time) {
+	r.Trips++
+	r.Until = now.Add(b.config.cooldown(r.Trips))
+	r.Probing = false
+}
+
+func (b *Shared) reset(r *Record) {
+	*r = Record{}
+}
+
+// Allow returns true if a new request should be allowed to proceed to the
+// underlying resource.
+func (b *Shared) Allow() bool {
+	r, err := b.load()
+	if err != nil || r.Until.IsZero() {
+		return true
+	}
+
+	if b.config.Now().Before(r.Until) {
+		return false
+	}
+
+	// Claim the probe unless another breaker did since loading.
+	allow := false
+	_, err = b.update(func(r *Record, now time.Time) {
+		switch {
+		case r.Until.IsZero():
+			allow = true
+		case now.Before(r.Until):
+			allow = false
+		default:
+			allow = true
+			r.Probing = true
+			r.Until = now.Add(b.config.Cooldown)
+		}
+	})
+
+	return allow || err != nil
+}
+
+// Success informs the breaker that a request to the underlying resource has
+// completed successfully, which closes an open circuit.
+func (b *Shared) Success(time.Duration) {
+	b.update(func(r *Record, now time.Time) {
+		if !r.Until.IsZero() {
+			b.reset(r)
+			return
+		}
+		b.count(r, now).Success++
+	})
+}
+
+// Failure informs the breaker that a request to the underlying resource has
+// failed.  Failing the probe opens the circuit again.
+func (b *Shared) Failure(time.Duration) {
+	b.update(func(r *Record, now time.Time) {
+		switch {
+		case r.Until.IsZero():
+			b.count(r, now).Failure++
+			if b.config.exceeded(r.Summary()) {
+				b.trip(r, now)
+			}
+		case r.Probing:
+			b.trip(r, now)
+		}
+	})
+}
+
+// Trip manually opens the circuit for every breaker sharing it.
+func (b *Shared) Trip() {
+	b.update(b.trip)
+}
+
+// Reset manually closes the circuit for every breaker sharing it and clears
+// its observations.
+func (b *Shared) Reset() {
+	b.update(func(r *Record, now time.Time) {
+		b.reset(r)
+	})
+}
+
+// State returns whether the shared circuit is currently closed, open or
+// half-open.
+func (b *Shared) State() State {
+	r, err := b.load()
+	switch {
+	case err != nil || r.Until.IsZero():
+		return Closed
+	case r.Probing || !b.config.Now().Before(r.Until):
+		return HalfOpen
+	}
+	return Open
+}
+
+// RetryAfter returns the remaining cooldown of an open circuit, after which
+// it allows a request to probe.  It returns 0 unless the circuit is open.
+func (b *Shared) RetryAfter() time.Duration {
+	r, err := b.load()
+	if err != nil || r.Until.IsZero() || r.Probing {
+		return 0
+	}
+	return remaining(r.Until, b.config.Now())
+}
+
+// Stats returns the observations made by every breaker sharing the circuit
+// over the current window.
+func (b *Shared) Stats() Summary {
+	r, err := b.load()
+	if err != nil {
+		return Summary{}
+	}
+	b.expire(&r, b.config.Now())
+	return r.Summary()
+}
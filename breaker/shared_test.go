@@ -0,0 +1,139 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSharedBreakersTripTogether(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	config := Config{
+		FailureRatio:    0.5,
+		MinObservations: 3,
+		Cooldown:        time.Second,
+		Now:             func() time.Time { return now },
+	}
+
+	a := NewShared(store, "upstream", config)
+	b := NewShared(store, "upstream", config)
+	other := NewShared(store, "other", config)
+
+	a.Success(0)
+	b.Failure(0)
+	a.Failure(0)
+
+	if !a.Allow() || !b.Allow() {
+		t.Fatal("expected to allow up to the minimum observations")
+	}
+
+	b.Failure(0)
+
+	if a.Allow() || b.Allow() {
+		t.Fatal("expected failures of both replicas to open the circuit for both")
+	}
+
+	if !other.Allow() {
+		t.Fatal("expected other keys to stay closed")
+	}
+
+	if want, got := Open, a.State(); want != got {
+		t.Fatalf("want %s, got %s", want, got)
+	}
+
+	if want, got := time.Second, b.RetryAfter(); want != got {
+		t.Fatalf("want %s, got %s", want, got)
+	}
+
+	now = now.Add(time.Second)
+
+	if !a.Allow() {
+		t.Fatal("expected to allow a probe after the cooldown")
+	}
+
+	if b.Allow() {
+		t.Fatal("expected to allow a single probe across breakers")
+	}
+
+	a.Success(0)
+
+	if !b.Allow() {
+		t.Fatal("expected the successful probe to close the circuit for both")
+	}
+
+	if want, got := (Summary{}), b.Stats(); want != got {
+		t.Fatalf("expected closing to clear the observations, got %+v", got)
+	}
+}
+
+func TestSharedFailedProbeTripsAgain(t *testing.T) {
+	now := time.Now()
+	b := NewShared(NewMemoryStore(), "upstream", Config{
+		CooldownPolicy: ExponentialCooldown(time.Second, time.Minute),
+		Now:            func() time.Time { return now },
+	})
+
+	b.Trip()
+	now = now.Add(time.Second)
+
+	if !b.Allow() {
+		t.Fatal("expected to allow a probe after the cooldown")
+	}
+
+	b.Failure(0)
+
+	if want, got := 2*time.Second, b.RetryAfter(); want != got {
+		t.Fatalf("expected the second trip to double the cooldown %s, got %s", want, got)
+	}
+
+	b.Reset()
+
+	if want, got := Closed, b.State(); want != got {
+		t.Fatalf("want %s, got %s", want, got)
+	}
+}
+
+func TestSharedExpiresObservations(t *testing.T) {
+	now := time.Now()
+	b := NewShared(NewMemoryStore(), "upstream", Config{
+		Window: 2 * time.Second,
+		Bucket: time.Second,
+		Now:    func() time.Time { return now },
+	})
+
+	b.Success(0)
+	now = now.Add(time.Second)
+	b.Success(0)
+
+	if want, got := uint(2), b.Stats().Total; want != got {
+		t.Fatalf("want %d, got %d", want, got)
+	}
+
+	now = now.Add(time.Second)
+
+	if want, got := uint(1), b.Stats().Total; want != got {
+		t.Fatalf("expected the first bucket to expire, want %d, got %d", want, got)
+	}
+}
+
+type failingStore struct{ err error }
+
+func (s failingStore) Load(string) (Record, error) { return Record{}, s.err }
+
+func (s failingStore) Update(string, func(*Record)) (Record, error) { return Record{}, s.err }
+
+func TestSharedAllowsWhenStoreFails(t *testing.T) {
+	err := errors.New("unavailable")
+	b := NewShared(failingStore{err}, "upstream", Config{})
+
+	b.Failure(0)
+
+	if !b.Allow() {
+		t.Fatal("expected to allow when the store fails")
+	}
+
+	if b.Err() != err {
+		t.Fatalf("want %v, got %v", err, b.Err())
+	}
+}
@@ -29,7 +29,7 @@ func TestSimulateConcurrentBreakerHandlerWithPartialFailures(t *testing.T) {
 	now := time.Now()
 	after := make(chan time.Time)
 
-	b := newBreaker(breakerConfig{
+	b := New(Config{
 		Window:          seconds * time.Second,
 		MinObservations: requestsPerSecond / seconds,
 		FailureRatio:    0.05,
@@ -0,0 +1,93 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// Store holds the Records of Shared breakers by key, so breakers of several
+// replicas sharing a store share the view of a dependency.
+type Store interface {
+	// Load returns the record of key, or the zero Record when there is
+	// none.
+	Load(key string) (Record, error)
+
+	// Update applies f to the record of key and stores the result, without
+	// any other Update of key in between.  It returns the stored record.
+	Update(key string, f func(*Record)) (Record, error)
+}
+
+// Record is the shared state of a breaker.
+type Record struct {
+	// Counts are the observations by bucket over the window.
+	Counts []Count `json:"counts,omitempty"`
+
+	// Until is the end of the cooldown of an open circuit, or of the probe
+	// of a half-open circuit.  The circuit is closed when zero.
+	Until time.Time `json:"until,omitempty"`
+
+	// Probing is set while a probe is allowed and has not reported.
+	Probing bool `json:"probing,omitempty"`
+
+	// Trips is the number of times the circuit tripped in a row since it
+	// closed.
+	Trips uint `json:"trips,omitempty"`
+}
+
+// Count is the number of observations made in a bucket of the window.
+type Count struct {
+	Bucket  int64 `json:"bucket"` // start of the bucket in bucket widths since the epoch
+	Success uint  `json:"success,omitempty"`
+	Failure uint  `json:"failure,omitempty"`
+}
+
+// Summary returns the observations of the record.
+func (r Record) Summary() Summary {
+	var sum Summary
+	for _, c := range r.Counts {
+		sum.Total += c.Success + c.Failure
+		sum.Errors += c.Failure
+	}
+	if sum.Total > 0 {
+		sum.Rate = float64(sum.Errors) / float64(sum.Total)
+	}
+	return sum
+}
+
+// MemoryStore is a Store for breakers in the same process.  It is safe for
+// concurrent use.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+// NewMemoryStore constructs an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+// Load returns the record of key.
+func (s *MemoryStore) Load(key string) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.records[key].clone(), nil
+}
+
+// Update applies f to the record of key.
+func (s *MemoryStore) Update(key string, f func(*Record)) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := s.records[key].clone()
+	f(&r)
+	s.records[key] = r
+
+	return r.clone(), nil
+}
+
+// clone copies the counts so callers can't change a stored record.
+func (r Record) clone() Record {
+	r.Counts = append([]Count(nil), r.Counts...)
+	return r
+}
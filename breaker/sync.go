@@ -0,0 +1,222 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// SyncCircuit is a Breaker with the semantics of a Circuit that guards its
+// state with a mutex instead of running a goroutine, so it needs no Close
+// and is collected like any other value.  Timeouts are observed when the
+// circuit is used, so Config.After is not needed.  It is safe for concurrent
+// use.
+type SyncCircuit struct {
+	config Config
+
+	mu        sync.Mutex
+	state     State
+	metrics   *metric
+	until     time.Time // end of the cooldown when open, or of the probes when half-open
+	probes    uint      // in flight when half-open
+	successes uint      // consecutive when half-open
+	trips     uint      // in a row since closed
+
+	transitions notifier
+}
+
+// NewSync constructs a new circuit breaker from the config, initially
+// closed.  Unset durations and clocks take their defaults.
+func NewSync(c Config) *SyncCircuit {
+	c = c.withDefaults()
+	return &SyncCircuit{
+		config:  c,
+		metrics: c.newMetric(),
+	}
+}
+
+// OnTransition registers f to be called with every change of State, like
+// Circuit.OnTransition.
+func (b *SyncCircuit) OnTransition(f func(Transition)) {
+	b.transitions.listen(f)
+}
+
+// transition changes the state, notifying listeners when it differs.
+func (b *SyncCircuit) transition(to State, now time.Time) {
+	if to != b.state {
+		b.transitions.notify(Transition{
+			From:    b.state,
+			To:      to,
+			Time:    now,
+			Summary: b.metrics.Summary(),
+		})
+		b.state = to
+	}
+}
+
+// expire applies the timeouts that passed by now.
+func (b *SyncCircuit) expire(now time.Time) {
+	for !now.Before(b.until) {
+		switch {
+		case b.state == Open:
+			b.transition(HalfOpen, now)
+			b.probes, b.successes = 0, 0
+			b.until = time.Time{}
+		case b.state == HalfOpen && b.probes == b.config.HalfOpenProbes:
+			// The probes did not report in time, trip from when they expired.
+			b.transition(Open, now)
+			b.trips++
+			b.until = b.until.Add(b.config.cooldown(b.trips))
+		default:
+			return
+		}
+	}
+}
+
+func (b *SyncCircuit) trip(now time.Time) {
+	b.transition(Open, now)
+	b.trips++
+	b.until = now.Add(b.config.cooldown(b.trips))
+}
+
+func (b *SyncCircuit) reset(now time.Time) {
+	b.transition(Closed, now)
+	b.metrics = b.config.newMetric()
+	b.trips = 0
+}
+
+// Allow returns true if a new request should be allowed to proceed to the
+// underlying resource.
+func (b *SyncCircuit) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.config.Now()
+	b.expire(now)
+
+	switch b.state {
+	case Closed:
+		return true
+	case HalfOpen:
+		if b.probes < b.config.HalfOpenProbes {
+			b.probes++
+			if b.probes == b.config.HalfOpenProbes {
+				// Trip again unless a probe reports within the cooldown.
+				b.until = now.Add(b.config.Cooldown)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Success informs the circuit that a request to the underlying resource has
+// completed successfully. Every Allowed request should signal either Success
+// or Failure.
+func (b *SyncCircuit) Success(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.config.Now()
+	b.expire(now)
+
+	switch b.state {
+	case Closed:
+		b.metrics.Success(d)
+		if b.config.SlowCallDuration > 0 && d >= b.config.SlowCallDuration && b.config.shouldOpen(b.metrics) {
+			b.trip(now)
+		}
+	case Open:
+		b.reset(now)
+	case HalfOpen:
+		b.successes++
+		if b.successes >= b.config.HalfOpenSuccesses {
+			b.reset(now)
+		} else if b.probes > 0 {
+			b.probes--
+			b.until = time.Time{}
+		}
+	}
+}
+
+// Failure informs the circuit that a request to the underlying resource has
+// failed. Every Allowed request should signal either Success or Failure.
+func (b *SyncCircuit) Failure(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.config.Now()
+	b.expire(now)
+
+	switch b.state {
+	case Closed:
+		b.metrics.Failure(d)
+		if b.config.shouldOpen(b.metrics) {
+			b.trip(now)
+		}
+	case HalfOpen:
+		b.trip(now)
+	}
+}
+
+// Ignore informs the circuit that an allowed request ended without counting
+// as Success or Failure, freeing its place when it probed.
+func (b *SyncCircuit) Ignore() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire(b.config.Now())
+
+	if b.state == HalfOpen && b.probes > 0 {
+		b.probes--
+		b.until = time.Time{}
+	}
+}
+
+// Trip manually opens the circuit.  It stays open for the cooldown before
+// probing like a circuit opened by failures.
+func (b *SyncCircuit) Trip() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trip(b.config.Now())
+}
+
+// Reset manually closes the circuit and clears its observations.
+func (b *SyncCircuit) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.reset(b.config.Now())
+}
+
+// State returns whether the circuit is currently closed, open or half-open.
+func (b *SyncCircuit) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire(b.config.Now())
+	return b.state
+}
+
+// RetryAfter returns the remaining cooldown of an open circuit, after which
+// it allows requests to probe.  It returns 0 unless the circuit is open.
+func (b *SyncCircuit) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.config.Now()
+	b.expire(now)
+	if b.state != Open {
+		return 0
+	}
+	return remaining(b.until, now)
+}
+
+// Stats returns the observations made over the current window.  The window
+// is cleared when the circuit closes after being open.
+func (b *SyncCircuit) Stats() Summary {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.metrics.Summary()
+}
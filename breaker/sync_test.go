@@ -0,0 +1,132 @@
+package breaker
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSyncCircuitTripsAtFailureRatio(t *testing.T) {
+	b := NewSync(Config{FailureRatio: 0.01, MinObservations: DefaultMinObservations})
+
+	for i := 0; i < 99; i++ {
+		b.Success(0)
+	}
+	b.Failure(0)
+
+	if !b.Allow() {
+		t.Fatal("expected failure to not trip circuit at 1% threshold")
+	}
+
+	b.Failure(0)
+
+	if b.Allow() {
+		t.Fatal("expected failure to trip over the threshold")
+	}
+
+	if got, want := b.Stats(), (Summary{Total: 101, Errors: 2, Rate: 2.0 / 101}); got != want {
+		t.Fatalf("expected stats %+v, got %+v", want, got)
+	}
+}
+
+func TestSyncCircuitHalfOpen(t *testing.T) {
+	now := time.Now()
+	b := NewSync(Config{
+		Cooldown:          time.Second,
+		HalfOpenProbes:    2,
+		HalfOpenSuccesses: 2,
+		Now:               func() time.Time { return now },
+	})
+
+	transitions := make(chan Transition, 10)
+	b.OnTransition(func(t Transition) { transitions <- t })
+
+	b.Trip()
+
+	if b.Allow() {
+		t.Fatal("expected to be open during the cooldown")
+	}
+
+	now = now.Add(time.Second)
+
+	if !b.Allow() || !b.Allow() || b.Allow() {
+		t.Fatal("expected to allow two probes after the cooldown")
+	}
+
+	// probes not reporting within a cooldown trip again
+	now = now.Add(time.Second)
+
+	if got, want := b.State(), Open; got != want {
+		t.Fatalf("expected to be %s when probes did not report, got %s", want, got)
+	}
+
+	now = now.Add(time.Second)
+
+	if !b.Allow() {
+		t.Fatal("expected to probe again after another cooldown")
+	}
+
+	b.Success(0)
+	b.Success(0)
+
+	if got, want := b.State(), Closed; got != want {
+		t.Fatalf("expected to be %s after the required successes, got %s", want, got)
+	}
+
+	for _, want := range []State{Open, HalfOpen, Open, HalfOpen, Closed} {
+		if got := (<-transitions).To; got != want {
+			t.Fatalf("expected transition to %s, got %s", want, got)
+		}
+	}
+}
+
+func TestCircuitClose(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	b := New(Config{})
+	b.Trip()
+	b.Close()
+
+	if !b.Allow() {
+		t.Fatal("expected a closed circuit to allow")
+	}
+
+	b.Failure(0)
+	b.Trip()
+
+	if got, want := b.State(), Closed; got != want {
+		t.Fatalf("expected a closed circuit to be %s, got %s", want, got)
+	}
+
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("expected the state machine to stop, %d goroutines before, %d after", before, after)
+	}
+}
+
+func benchmarkBreaker(b *testing.B, breaker Breaker) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if breaker.Allow() {
+				breaker.Success(0)
+			}
+		}
+	})
+}
+
+func BenchmarkCircuit(b *testing.B) {
+	c := New(Config{})
+	defer c.Close()
+	benchmarkBreaker(b, c)
+}
+
+func BenchmarkSyncCircuit(b *testing.B) {
+	benchmarkBreaker(b, NewSync(Config{}))
+}
+
+func BenchmarkConsecutive(b *testing.B) {
+	benchmarkBreaker(b, NewConsecutive(ConsecutiveConfig{}))
+}
@@ -0,0 +1,155 @@
+package breaker
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// DefaultThrottleK is the default multiplier of accepted requests a Throttle
+// lets through before rejecting locally.
+const DefaultThrottleK = 2.0
+
+// ThrottleConfig parameterizes a Throttle.
+type ThrottleConfig struct {
+	// K is the multiplier of the accepted requests that may be attempted
+	// before rejecting locally.  Lower values shed load more aggressively,
+	// higher values let more requests through to the underlying resource.
+	// Defaults to DefaultThrottleK, and is at least 1.
+	K float64
+
+	// Window is the period over which requests and accepts are counted,
+	// defaulting to DefaultWindow.
+	Window time.Duration
+
+	// Bucket is the width of the buckets the window is split in, defaulting
+	// to DefaultBucket.
+	Bucket time.Duration
+
+	// Now is the clock of the window, defaulting to time.Now.
+	Now func() time.Time
+
+	// Rand returns a pseudo-random number in [0.0,1.0), defaulting to
+	// rand.Float64.
+	Rand func() float64
+}
+
+// Throttle is a Breaker implementing client-side adaptive throttling.  Rather
+// than opening at a failure ratio, it rejects requests locally with a
+// probability that grows with the requests attempted over the window beyond
+// K times those accepted by the underlying resource:
+//
+//	max(0, (requests - K * accepts) / (requests + 1))
+//
+// Requests rejected locally count as attempted, so a failing resource is
+// progressively shed, and load recovers smoothly as it accepts again.  It is
+// safe for concurrent use.
+type Throttle struct {
+	config ThrottleConfig
+
+	mu sync.Mutex
+	// accepts are counted as successes, and requests that failed or were
+	// rejected locally as failures, so requests are the total.
+	metrics *metric
+}
+
+// NewThrottle constructs a new adaptive throttle from the config.  Unset
+// values take their defaults.
+func NewThrottle(c ThrottleConfig) *Throttle {
+	if c.K == 0 {
+		c.K = DefaultThrottleK
+	}
+
+	if c.K < 1.0 {
+		c.K = 1.0
+	}
+
+	if c.Window == 0 {
+		c.Window = DefaultWindow
+	}
+
+	if c.Bucket <= 0 {
+		c.Bucket = DefaultBucket
+	}
+
+	if c.Bucket > c.Window {
+		c.Bucket = c.Window
+	}
+
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+
+	if c.Rand == nil {
+		c.Rand = rand.Float64
+	}
+
+	return &Throttle{
+		config:  c,
+		metrics: newMetric(c.Window, c.Bucket, c.Now),
+	}
+}
+
+// probability returns the chance to reject a request locally.
+func (t *Throttle) probability() float64 {
+	t.metrics.next() // expire the buckets that left the window
+	s := t.metrics.Summary()
+	requests := float64(s.Total)
+	accepts := float64(s.Total - s.Errors)
+
+	p := (requests - t.config.K*accepts) / (requests + 1)
+	if p < 0.0 {
+		return 0.0
+	}
+	return p
+}
+
+// Allow returns true if a new request should be allowed to proceed to the
+// underlying resource, rejecting it with the current Probability otherwise.
+func (t *Throttle) Allow() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.config.Rand() < t.probability() {
+		t.metrics.Failure(0)
+		return false
+	}
+	return true
+}
+
+// Success informs the throttle that a request was accepted by the underlying
+// resource.
+func (t *Throttle) Success(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.metrics.Success(d)
+}
+
+// Failure informs the throttle that a request was not accepted by the
+// underlying resource.
+func (t *Throttle) Failure(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.metrics.Failure(d)
+}
+
+// Probability returns the current chance between 0.0 and 1.0 that Allow
+// rejects a request.
+func (t *Throttle) Probability() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.probability()
+}
+
+// Stats returns the observations made over the current window, where the
+// errors include the requests rejected locally.
+func (t *Throttle) Stats() Summary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.metrics.next()
+	return t.metrics.Summary()
+}
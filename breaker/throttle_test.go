@@ -0,0 +1,83 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottleAllowsWhileAccepted(t *testing.T) {
+	b := NewThrottle(ThrottleConfig{Rand: func() float64 { return 0.0 }})
+
+	for i := 0; i < 100; i++ {
+		if !b.Allow() {
+			t.Fatalf("expected to allow request %d while all are accepted", i)
+		}
+		b.Success(0)
+	}
+
+	if got := b.Probability(); got != 0.0 {
+		t.Fatalf("expected no rejections, got probability %f", got)
+	}
+}
+
+func TestThrottleRejectsWithProbability(t *testing.T) {
+	b := NewThrottle(ThrottleConfig{K: 2, Rand: func() float64 { return 0.5 }})
+
+	for i := 0; i < 10; i++ {
+		b.Success(0)
+	}
+
+	// 30 requests of 10 accepted are within K=2 up to 20 requests
+	for i := 0; i < 20; i++ {
+		b.Failure(0)
+	}
+
+	if want, got := 10.0/31, b.Probability(); want != got {
+		t.Fatalf("expected probability %f, got %f", want, got)
+	}
+
+	if !b.Allow() {
+		t.Fatal("expected to allow below the probability")
+	}
+
+	for i := 0; i < 30; i++ {
+		b.Failure(0)
+	}
+
+	// (60 - 2*10) / 61
+	if want, got := 40.0/61, b.Probability(); want != got {
+		t.Fatalf("expected probability %f, got %f", want, got)
+	}
+
+	if b.Allow() {
+		t.Fatal("expected to reject above the probability")
+	}
+
+	if want, got := (Summary{Total: 61, Errors: 51, Rate: 51.0 / 61}), b.Stats(); want != got {
+		t.Fatalf("expected rejections to count as requests %+v, got %+v", want, got)
+	}
+}
+
+func TestThrottleRecoversAfterWindow(t *testing.T) {
+	now := time.Now()
+	b := NewThrottle(ThrottleConfig{
+		Window: 2 * time.Second,
+		Bucket: time.Second,
+		Now:    func() time.Time { return now },
+		Rand:   func() float64 { return 0.0 },
+	})
+
+	for i := 0; i < 100; i++ {
+		b.Failure(0)
+	}
+
+	if b.Allow() {
+		t.Fatal("expected to reject when nothing is accepted")
+	}
+
+	now = now.Add(2 * time.Second)
+
+	if !b.Allow() {
+		t.Fatal("expected to allow once the failures left the window")
+	}
+}
@@ -0,0 +1,66 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// Transition describes a change of the observable State of a Circuit.
+type Transition struct {
+	From    State
+	To      State
+	Time    time.Time // from the clock of the circuit
+	Summary Summary   // observations in the window at the time of change
+}
+
+// notifier delivers transitions to listeners in order on its own goroutine,
+// so listeners can neither block nor deadlock the state machine.  The
+// goroutine only runs while transitions are pending.
+type notifier struct {
+	mu        sync.Mutex
+	listeners []func(Transition)
+	pending   []Transition
+	running   bool
+}
+
+func (n *notifier) listen(f func(Transition)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.listeners = append(n.listeners, f)
+}
+
+func (n *notifier) notify(t Transition) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.listeners) == 0 {
+		return
+	}
+
+	n.pending = append(n.pending, t)
+	if !n.running {
+		n.running = true
+		go n.deliver()
+	}
+}
+
+func (n *notifier) deliver() {
+	for {
+		n.mu.Lock()
+		if len(n.pending) == 0 {
+			n.running = false
+			n.mu.Unlock()
+			return
+		}
+
+		t := n.pending[0]
+		n.pending = n.pending[1:]
+		listeners := n.listeners
+		n.mu.Unlock()
+
+		for _, f := range listeners {
+			f(t)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransitions(t *testing.T) {
+	after := make(chan time.Time)
+	now := time.Now()
+
+	b := New(Config{
+		Now:   func() time.Time { return now },
+		After: func(time.Duration) <-chan time.Time { return after },
+	})
+
+	transitions := make(chan Transition, 10)
+	b.OnTransition(func(t Transition) { transitions <- t })
+
+	b.Success(0)
+	b.Failure(0)
+
+	after <- now
+
+	if !b.Allow() {
+		t.Fatal("expected to allow once after cooldown")
+	}
+
+	b.Success(0)
+
+	want := []Transition{
+		{From: Closed, To: Open, Time: now, Summary: Summary{Total: 2, Errors: 1, Rate: 0.5}},
+		{From: Open, To: HalfOpen, Time: now, Summary: Summary{Total: 2, Errors: 1, Rate: 0.5}},
+		{From: HalfOpen, To: Closed, Time: now, Summary: Summary{Total: 2, Errors: 1, Rate: 0.5}},
+	}
+
+	for i, w := range want {
+		select {
+		case got := <-transitions:
+			if got != w {
+				t.Fatalf("transition %d: expected %+v, got %+v", i, w, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("transition %d: expected %+v, got none", i, w)
+		}
+	}
+}
+
+func TestTransitionListenerCannotBlock(t *testing.T) {
+	b := New(Config{})
+
+	block := make(chan struct{})
+	defer close(block)
+
+	b.OnTransition(func(Transition) { <-block })
+	b.OnTransition(func(Transition) { b.State() })
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			b.Trip()
+			b.Reset()
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected a blocked listener not to block the circuit")
+	}
+}
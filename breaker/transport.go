@@ -20,34 +20,73 @@ type ResponseValidator func(*http.Response) bool
 // Transport produces an http.RoundTripper that's governed by the passed
 // Breaker and ResponseValidator. Responses that fail the validator signal
 // failures to the breaker. Once the breaker opens, outgoing requests are
-// terminated before being forwarded with ErrCircuitOpen.
+// terminated before being forwarded with ErrCircuitOpen, or ErrBulkheadFull
+// when rejected by a Bulkhead.
+//
+// Errors are classified by the breaker when it is a ContextBreaker, or else
+// by the DefaultErrorClassifier, so requests canceled by their context don't
+// count as failures, while those exceeding their deadline do.  Canceled
+// requests are reported to breakers implementing Ignorer.
 func Transport(breaker Breaker, validator ResponseValidator, next http.RoundTripper) http.RoundTripper {
 	return &transport{
-		breaker:   breaker,
+		breaker:   asContextBreaker(breaker),
 		validator: validator,
 		next:      next,
 	}
 }
 
+func asContextBreaker(b Breaker) ContextBreaker {
+	if cb, ok := b.(ContextBreaker); ok {
+		return cb
+	}
+	return WithContext(b, nil)
+}
+
+// rejecter is a breaker rejecting requests with its own error rather than
+// ErrCircuitOpen, like a Bulkhead.
+type rejecter interface {
+	rejection() error
+}
+
 type transport struct {
-	breaker   Breaker
+	breaker   ContextBreaker
 	validator ResponseValidator
 	next      http.RoundTripper
 }
 
 func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
-	if !t.breaker.Allow() {
+	return roundTrip(t.breaker, t.validator, t.next, req)
+}
+
+func roundTrip(breaker ContextBreaker, validator ResponseValidator, next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !breaker.AllowContext(ctx) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if r, ok := breaker.(rejecter); ok {
+			return nil, r.rejection()
+		}
 		return nil, ErrCircuitOpen
 	}
 
 	begin := time.Now()
-	resp, err := t.next.RoundTrip(req)
+	resp, err := next.RoundTrip(req)
 
 	duration := time.Since(begin)
-	if err != nil || !t.validator(resp) {
-		t.breaker.Failure(duration)
-	} else {
-		t.breaker.Success(duration)
+	switch {
+	case err != nil:
+		// Transports wrap the cause of a canceled request inconsistently,
+		// classify by the context when it is done.
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			breaker.Report(duration, ctxErr)
+		} else {
+			breaker.Report(duration, err)
+		}
+	case !validator(resp):
+		breaker.Failure(duration)
+	default:
+		breaker.Success(duration)
 	}
 
 	return resp, err
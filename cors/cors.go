@@ -12,6 +12,7 @@ package cors
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,17 +20,71 @@ import (
 // configured.
 const DefaultAllowOrigin = "*"
 
+// DefaultMaxAge sets Access-Control-Max-Age of preflight requests when not
+// configured.
+const DefaultMaxAge = 10 * time.Minute
+
+var (
+	// DefaultAllowMethods sets Access-Control-Allow-Methods when not
+	// configured.
+	DefaultAllowMethods = []string{"GET"}
+
+	// DefaultAllowHeaders sets Access-Control-Allow-Headers when not
+	// configured.
+	DefaultAllowHeaders = []string{"Accept", "Accept-Encoding", "Authorization", "Content-Type", "Origin"}
+)
+
 // Config parameterizes CORS behavior.
 type Config struct {
 	// AllowOrigin transforms a request into the Access-Control-Allow-Origin
 	// header, default is full access "*".
 	AllowOrigin func(*http.Request) string
+
+	// AllowMethods are the methods of requests passed to the next handler
+	// and allowed by preflight requests, default is DefaultAllowMethods.
+	// HEAD is allowed with GET.  Other methods are answered with 405.
+	AllowMethods []string
+
+	// AllowHeaders are the request headers allowed by preflight requests,
+	// default is DefaultAllowHeaders.
+	AllowHeaders []string
+
+	// ExposeHeaders are the response headers the client may read beyond the
+	// simple response headers, default is none.
+	ExposeHeaders []string
+
+	// AllowCredentials lets the client send cookies and authorization.  As
+	// credentials must not be allowed for any origin, it requires
+	// AllowOrigin, and is left out of responses allowing the origin "*".
+	AllowCredentials bool
+
+	// MaxAge is how long the client may cache a preflight response, default
+	// is DefaultMaxAge.  Negative durations omit Access-Control-Max-Age.
+	MaxAge time.Duration
 }
 
 // Middleware returns a middleware that applies Config to the request.
 func Middleware(cfg Config) func(http.Handler) http.Handler {
-	const maxAge = 10 * time.Minute
-	age := strconv.Itoa(int(maxAge / time.Second))
+	if cfg.AllowCredentials && cfg.AllowOrigin == nil {
+		panic("cors: AllowCredentials requires AllowOrigin")
+	}
+
+	if cfg.AllowMethods == nil {
+		cfg.AllowMethods = DefaultAllowMethods
+	}
+
+	if cfg.AllowHeaders == nil {
+		cfg.AllowHeaders = DefaultAllowHeaders
+	}
+
+	if cfg.MaxAge == 0 {
+		cfg.MaxAge = DefaultMaxAge
+	}
+
+	methods := strings.Join(cfg.AllowMethods, ", ")
+	headers := strings.Join(cfg.AllowHeaders, ", ")
+	expose := strings.Join(cfg.ExposeHeaders, ", ")
+	age := strconv.Itoa(int(cfg.MaxAge / time.Second))
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -38,26 +93,71 @@ func Middleware(cfg Config) func(http.Handler) http.Handler {
 				origin = cfg.AllowOrigin(r)
 			}
 
-			w.Header().Set("Access-Control-Allow-Methods", "GET")
-			w.Header().Set("Access-Control-Allow-Headers", "Accept, Accept-Encoding, Authorization, Content-Type, Origin")
+			// Credentials are never allowed for any origin.
+			if cfg.AllowCredentials && origin != "*" {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			w.Header().Set("Access-Control-Allow-Headers", headers)
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 
-			switch r.Method {
-			default:
-				w.WriteHeader(http.StatusMethodNotAllowed)
-			case "OPTIONS":
-				if r.Header.Get("Access-Control-Request-Method") == "GET" {
-					w.Header().Set("Access-Control-Max-Age", age)
+			switch {
+			case r.Method == "OPTIONS":
+				if cfg.allowMethod(r.Header.Get("Access-Control-Request-Method")) &&
+					cfg.allowHeaders(r.Header.Get("Access-Control-Request-Headers")) {
+					if cfg.MaxAge > 0 {
+						w.Header().Set("Access-Control-Max-Age", age)
+					}
 					return
 				}
 				w.WriteHeader(http.StatusUnauthorized)
-			case "HEAD", "GET":
+			case cfg.allowMethod(r.Method):
+				if expose != "" {
+					w.Header().Set("Access-Control-Expose-Headers", expose)
+				}
 				next.ServeHTTP(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
 			}
 		})
 	}
 }
 
+// allowMethod returns whether the method is allowed, HEAD also when GET is.
+func (cfg Config) allowMethod(method string) bool {
+	for _, m := range cfg.AllowMethods {
+		if m == method || method == "HEAD" && m == "GET" {
+			return true
+		}
+	}
+	return false
+}
+
+// allowHeaders returns whether every header of the comma separated list is
+// allowed, compared case-insensitively.
+func (cfg Config) allowHeaders(list string) bool {
+	for _, h := range strings.Split(list, ",") {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			continue
+		}
+
+		allowed := false
+		for _, a := range cfg.AllowHeaders {
+			if strings.EqualFold(a, h) {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
 // Get implements a simple read-only access control policy handling preflight
 // and normal requests with a cache age of 10 minutes for preflight requests.
 // Methods other than HEAD, OPTIONS, GET will return 405.
@@ -10,6 +10,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 type code int
@@ -93,3 +94,158 @@ func TestGetWithPOST(t *testing.T) {
 		t.Fatalf("expected 405 for GET, got: %d", res)
 	}
 }
+
+func TestMiddlewarePreflightAllowedMethod(t *testing.T) {
+	h := Middleware(Config{
+		AllowMethods: []string{"GET", "PUT", "DELETE"},
+		AllowHeaders: []string{"Content-Type", "X-Request-Id"},
+		MaxAge:       time.Hour,
+	})(code(404))
+
+	for _, test := range []struct {
+		method, headers string
+		code            int
+	}{
+		{"PUT", "content-type, x-request-id", 200},
+		{"DELETE", "", 200},
+		{"POST", "", http.StatusUnauthorized},
+		{"PUT", "X-Unknown", http.StatusUnauthorized},
+	} {
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest("OPTIONS", "/", nil)
+		req.Header.Set("Origin", "localhost")
+		req.Header.Set("Access-Control-Request-Method", test.method)
+		req.Header.Set("Access-Control-Request-Headers", test.headers)
+
+		h.ServeHTTP(resp, req)
+
+		if want, got := test.code, resp.Code; want != got {
+			t.Errorf("%s %q: want %d, got %d", test.method, test.headers, want, got)
+		}
+
+		if want, got := "GET, PUT, DELETE", resp.Header().Get("Access-Control-Allow-Methods"); want != got {
+			t.Errorf("want %q, got %q", want, got)
+		}
+
+		if test.code == 200 {
+			if want, got := "3600", resp.Header().Get("Access-Control-Max-Age"); want != got {
+				t.Errorf("want %q, got %q", want, got)
+			}
+		}
+	}
+}
+
+func TestMiddlewarePassesAllowedMethods(t *testing.T) {
+	h := Middleware(Config{
+		AllowMethods:  []string{"GET", "POST"},
+		ExposeHeaders: []string{"Location", "X-Request-Id"},
+	})(code(201))
+
+	for _, test := range []struct {
+		method string
+		code   int
+	}{
+		{"POST", 201},
+		{"HEAD", 201},
+		{"DELETE", http.StatusMethodNotAllowed},
+	} {
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, httptest.NewRequest(test.method, "/", nil))
+
+		if want, got := test.code, resp.Code; want != got {
+			t.Errorf("%s: want %d, got %d", test.method, want, got)
+		}
+	}
+
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest("POST", "/", nil))
+
+	if want, got := "Location, X-Request-Id", resp.Header().Get("Access-Control-Expose-Headers"); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestMiddlewareAllowsHEADAlone(t *testing.T) {
+	h := Middleware(Config{AllowMethods: []string{"HEAD"}})(code(200))
+
+	for _, test := range []struct {
+		method string
+		code   int
+	}{
+		{"HEAD", 200},
+		{"GET", http.StatusMethodNotAllowed},
+	} {
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, httptest.NewRequest(test.method, "/", nil))
+
+		if want, got := test.code, resp.Code; want != got {
+			t.Errorf("%s: want %d, got %d", test.method, want, got)
+		}
+	}
+}
+
+func TestMiddlewareAllowCredentials(t *testing.T) {
+	h := Middleware(Config{
+		AllowOrigin:      func(*http.Request) string { return "https://example.com" },
+		AllowCredentials: true,
+	})(code(200))
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "https://example.com")
+
+	h.ServeHTTP(resp, req)
+
+	if want, got := "true", resp.Header().Get("Access-Control-Allow-Credentials"); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	if want, got := "https://example.com", resp.Header().Get("Access-Control-Allow-Origin"); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestMiddlewareNeverAllowsCredentialsForAnyOrigin(t *testing.T) {
+	h := Middleware(Config{
+		AllowOrigin:      func(*http.Request) string { return "*" },
+		AllowCredentials: true,
+	})(code(200))
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "https://evil.example")
+
+	h.ServeHTTP(resp, req)
+
+	if got := resp.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("expected no credentials for any origin, got %q", got)
+	}
+
+	if want, got := "*", resp.Header().Get("Access-Control-Allow-Origin"); want != got {
+		t.Errorf("expected the origin not to be reflected, want %q, got %q", want, got)
+	}
+}
+
+func TestMiddlewareCredentialsRequireAllowOrigin(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected to panic without AllowOrigin")
+		}
+	}()
+
+	Middleware(Config{AllowCredentials: true})
+}
+
+func TestMiddlewareOmitsNegativeMaxAge(t *testing.T) {
+	h := Middleware(Config{MaxAge: -1})(code(404))
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest("OPTIONS", "/", nil)
+	req.Header.Set("Access-Control-Request-Method", "GET")
+
+	h.ServeHTTP(resp, req)
+
+	if got := resp.Header().Get("Access-Control-Max-Age"); got != "" {
+		t.Errorf("expected no max age, got %q", got)
+	}
+}